@@ -0,0 +1,53 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/theWebPartyTime/server/internal/channels"
+	"github.com/theWebPartyTime/server/internal/colors"
+
+	"github.com/centrifugal/centrifuge"
+	"github.com/gin-gonic/gin"
+)
+
+func listRooms(c *gin.Context) {
+	rmManager().Mu.RLock()
+	defer rmManager().Mu.RUnlock()
+
+	c.JSON(http.StatusOK, rmManager().Rooms())
+}
+
+// closeRoom shuts a live room down the same way its owner leaving does,
+// sending everyone in it away.
+func closeRoom(node *centrifuge.Node) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		roomCode := c.Param("room_code")
+
+		rmManager().Mu.Lock()
+		_, roomMu, roomExists := rmManager().Room(roomCode)
+		if !roomExists {
+			rmManager().Mu.Unlock()
+			c.JSON(http.StatusNotFound, gin.H{"error": "room not found"})
+			return
+		}
+
+		roomMu.Lock()
+		rmManager().Close(roomCode)
+		roomMu.Unlock()
+		rmManager().Mu.Unlock()
+
+		log.Printf("[%v] Room closed by a moderator", colors.Left(roomCode))
+
+		unsubscribeRequest, _ := json.Marshal(response{
+			Type:    "unsubscribe",
+			Message: map[string]any{"reason": "closed_by_moderator"},
+		})
+
+		node.Publish(channels.GetPlayPrefix()+roomCode, unsubscribeRequest)
+		node.Publish(channels.GetSpectatePrefix()+roomCode, unsubscribeRequest)
+
+		c.JSON(http.StatusOK, gin.H{"status": "room closed"})
+	}
+}
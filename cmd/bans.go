@@ -0,0 +1,149 @@
+package main
+
+import (
+	"encoding/json"
+
+	"github.com/centrifugal/centrifuge"
+	"github.com/theWebPartyTime/server/internal/channels"
+)
+
+const maxKickReasonLength = 200
+
+type cohostRequest struct {
+	UserID string `json:"userID"`
+	Cohost bool   `json:"cohost"`
+}
+
+type unbanRequest struct {
+	ID string `json:"id"`
+}
+
+// setCohost lets the owner of a room hand kicking and banning to a player.
+func setCohost(client *centrifuge.Client, data []byte) *centrifuge.Error {
+	var request cohostRequest
+	if err := json.Unmarshal(data, &request); err != nil || request.UserID == "" {
+		return &centrifuge.Error{Code: 400, Message: "Data provided to the remote procedure is invalid."}
+	}
+
+	rmManager().Mu.Lock()
+	defer rmManager().Mu.Unlock()
+
+	room, roomMu, roomFound := rmManager().ByOwner(client.UserID())
+	if !roomFound {
+		return &centrifuge.Error{Code: 400, Message: "User does not own any room."}
+	}
+
+	roomMu.Lock()
+	defer roomMu.Unlock()
+
+	if err := room.SetCohost(request.UserID, request.Cohost); err != nil {
+		return &centrifuge.Error{Code: 400, Message: err.Error()}
+	}
+
+	return nil
+}
+
+func unban(client *centrifuge.Client, data []byte) *centrifuge.Error {
+	var request unbanRequest
+	if err := json.Unmarshal(data, &request); err != nil || request.ID == "" {
+		return &centrifuge.Error{Code: 400, Message: "Data provided to the remote procedure is invalid."}
+	}
+
+	rmManager().Mu.Lock()
+	defer rmManager().Mu.Unlock()
+
+	room, roomMu, roomFound := rmManager().Room(channels.RoomCode(client.Channels()))
+	if !roomFound {
+		return &centrifuge.Error{Code: 400, Message: "User is not in any room."}
+	}
+
+	roomMu.Lock()
+	defer roomMu.Unlock()
+
+	if !room.IsHost(client.UserID()) {
+		return &centrifuge.Error{Code: 403, Message: "Only hosts of a room can lift its bans."}
+	}
+
+	if err := room.Unban(client.UserID(), request.ID); err != nil {
+		return &centrifuge.Error{Code: 404, Message: err.Error()}
+	}
+
+	return nil
+}
+
+func listBans(client *centrifuge.Client) ([]byte, *centrifuge.Error) {
+	rmManager().Mu.RLock()
+	defer rmManager().Mu.RUnlock()
+
+	room, roomMu, roomFound := rmManager().Room(channels.RoomCode(client.Channels()))
+	if !roomFound {
+		return nil, &centrifuge.Error{Code: 400, Message: "User is not in any room."}
+	}
+
+	roomMu.RLock()
+	defer roomMu.RUnlock()
+
+	if !room.IsHost(client.UserID()) {
+		return nil, &centrifuge.Error{Code: 403, Message: "Only hosts of a room can see its bans."}
+	}
+
+	response, _ := json.Marshal(map[string]any{"bans": room.Bans()})
+	return response, nil
+}
+
+// kick sends a user away from the room of a host, banning them if asked.
+// The room locks are released before unsubscribing the user, since that
+// runs their unsubscribe handler right away.
+func kick(node *centrifuge.Node, client *centrifuge.Client, content map[string]any) {
+	target, _ := content["userID"].(string)
+	reason, _ := content["reason"].(string)
+	reason = truncateReason(reason)
+	ban, _ := content["ban"].(bool)
+
+	rmManager().Mu.Lock()
+	room, roomMu, roomFound := rmManager().Room(channels.RoomCode(client.Channels()))
+	if !roomFound {
+		rmManager().Mu.Unlock()
+		return
+	}
+
+	roomMu.Lock()
+	if !room.CanKick(client.UserID(), target) {
+		roomMu.Unlock()
+		rmManager().Mu.Unlock()
+		return
+	}
+
+	banned := room.Kick(client.UserID(), target, reason, ban)
+	roomCode := room.GetCode()
+	roomMu.Unlock()
+	rmManager().Mu.Unlock()
+
+	kicked, _ := json.Marshal(response{
+		Type:    "kicked",
+		Message: map[string]any{"reason": reason, "banned": banned != nil},
+	})
+	sendToUser(node, target, kicked)
+
+	playChannel := channels.GetPlayPrefix() + roomCode
+	watchChannel := channels.GetSpectatePrefix() + roomCode
+
+	unsubscribeRequest, _ := json.Marshal(response{
+		Type:    "remove_nickname",
+		Message: map[string]any{"id": target},
+	})
+
+	node.Publish(playChannel, unsubscribeRequest)
+	node.Publish(watchChannel, unsubscribeRequest)
+	node.Unsubscribe(target, playChannel)
+	node.Unsubscribe(target, watchChannel)
+}
+
+func truncateReason(reason string) string {
+	runes := []rune(reason)
+	if len(runes) > maxKickReasonLength {
+		return string(runes[:maxKickReasonLength])
+	}
+
+	return reason
+}
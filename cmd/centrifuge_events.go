@@ -1,14 +1,18 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"log"
 	"time"
 
 	"github.com/centrifugal/centrifuge"
+	"github.com/theWebPartyTime/server/internal/auth"
 	"github.com/theWebPartyTime/server/internal/channels"
 	"github.com/theWebPartyTime/server/internal/colors"
 	"github.com/theWebPartyTime/server/internal/room"
+	"github.com/theWebPartyTime/server/internal/service"
 )
 
 type createRequest struct {
@@ -44,10 +48,13 @@ func onRPC(node *centrifuge.Node, client *centrifuge.Client) func(centrifuge.RPC
 						node.Publish(channels.GetPlayPrefix()+roomCode, data)
 					}, func(roomCode string, data []byte) {
 						node.Publish(channels.GetSpectatePrefix()+roomCode, data)
+					}, func(userID string, data []byte) {
+						sendToUser(node, userID, data)
 					})
 
 				room, roomMu, _ := rmManager().Room(roomCode)
 				roomMu.Lock()
+				room.SetIdentity(client.UserID(), connectionIdentity(client.Info()))
 				room.SetOnStart(func() {
 					log.Printf("\nstarted 123\n")
 					startMsg, _ := json.Marshal(response{
@@ -77,8 +84,8 @@ func onRPC(node *centrifuge.Node, client *centrifuge.Client) func(centrifuge.RPC
 			room, roomMu, roomFound := rmManager().ByOwner(client.UserID())
 
 			if roomFound {
-				roomMu.RLock()
-				defer roomMu.RUnlock()
+				roomMu.Lock()
+				defer roomMu.Unlock()
 				err := room.Start(false)
 				if err != nil {
 					centrifugeError = &centrifuge.Error{
@@ -98,6 +105,55 @@ func onRPC(node *centrifuge.Node, client *centrifuge.Client) func(centrifuge.RPC
 				}
 			}
 
+		case "createInvite":
+			RPCResponse, centrifugeError = createInvite(client.UserID(), e.Data)
+
+		case "setRoomPin":
+			centrifugeError = setRoomPin(client.UserID(), e.Data)
+
+		case "setCohost":
+			centrifugeError = setCohost(client, e.Data)
+
+		case "unban":
+			centrifugeError = unban(client, e.Data)
+
+		case "listBans":
+			RPCResponse, centrifugeError = listBans(client)
+
+		case "replayParty":
+			var data replayRequest
+			err := json.Unmarshal(e.Data, &data)
+			account, authenticated := auth.AccountFromInfo(client.Info())
+
+			speed := 1.0
+			if data.Speed != nil {
+				speed = *data.Speed
+			}
+
+			if err != nil || data.PartyID == 0 || !validReplaySpeed(speed) {
+				centrifugeError = &centrifuge.Error{
+					Code: 400, Message: "Data provided to the remote procedure is invalid."}
+			} else if !authenticated {
+				centrifugeError = &centrifuge.Error{
+					Code: 401, Message: "Replays are only available to logged in users."}
+			} else if partyLog == nil {
+				centrifugeError = &centrifuge.Error{
+					Code: 503, Message: "Party history is unavailable."}
+			} else {
+				events, err := partyLog.ReplayEvents(context.Background(), data.PartyID, account, data.From)
+				if errors.Is(err, service.ErrPartyNotFound) {
+					centrifugeError = &centrifuge.Error{Code: 404, Message: err.Error()}
+				} else if err != nil {
+					centrifugeError = &centrifuge.Error{Code: 500, Message: err.Error()}
+				} else if !startReplay(client) {
+					centrifugeError = &centrifuge.Error{
+						Code: 429, Message: "A replay is already running."}
+				} else {
+					go replayParty(client, events, speed)
+					RPCResponse, _ = json.Marshal(map[string]any{"events": len(events)})
+				}
+			}
+
 		default:
 			centrifugeError = centrifuge.ErrorMethodNotFound
 		}
@@ -110,6 +166,19 @@ func onRPC(node *centrifuge.Node, client *centrifuge.Client) func(centrifuge.RPC
 	}
 }
 
+// connectionIdentity tells who a connection was linked to when it was
+// established.
+func connectionIdentity(info []byte) room.Identity {
+	identity := room.Identity{Network: auth.NetworkFromInfo(info)}
+	if account, ok := auth.AccountFromInfo(info); ok {
+		identity.Account = account
+		return identity
+	}
+
+	identity.Guest, _ = auth.GuestFromInfo(info)
+	return identity
+}
+
 func onDisconnect(client *centrifuge.Client) func(centrifuge.DisconnectEvent) {
 	return func(e centrifuge.DisconnectEvent) {
 	}
@@ -117,6 +186,22 @@ func onDisconnect(client *centrifuge.Client) func(centrifuge.DisconnectEvent) {
 
 func onSubscribe(node *centrifuge.Node, client *centrifuge.Client) func(centrifuge.SubscribeEvent, centrifuge.SubscribeCallback) {
 	return func(e centrifuge.SubscribeEvent, cb centrifuge.SubscribeCallback) {
+		join := parseJoinRequest(e.Data)
+		nickname := join.Nickname
+
+		identity := connectionIdentity(client.Info())
+		preferredLanguage := ""
+
+		if identity.Authenticated() && userProfiles != nil && channels.AsRoomChannel(e.Channel) != nil {
+			user, err := userProfiles.GetUser(context.Background(), identity.Account)
+			if err == nil {
+				if nickname == "" {
+					nickname = user.DisplayName
+				}
+				preferredLanguage = user.Language
+			}
+		}
+
 		rmManager().Mu.Lock()
 		defer rmManager().Mu.Unlock()
 
@@ -135,17 +220,26 @@ func onSubscribe(node *centrifuge.Node, client *centrifuge.Client) func(centrifu
 					return
 				}
 
-				var nickname string
-				json.Unmarshal(e.Data, &nickname)
+				roomMu.Lock()
+				if err := admit(room, client.UserID(), join, channels.IsWatch(e.Channel)); err != nil {
+					roomMu.Unlock()
+					cb(centrifuge.SubscribeReply{}, err)
+					return
+				}
 
-				if !room.CanJoin(client.UserID(), channels.IsWatch(e.Channel)) {
+				if !room.CanJoin(client.UserID(), identity, channels.IsWatch(e.Channel)) {
+					roomMu.Unlock()
 					cb(centrifuge.SubscribeReply{}, centrifuge.ErrorPermissionDenied)
 					return
 				}
 
-				nickname = room.Joined(client.UserID(), nickname)
+				nickname = room.Joined(client.UserID(), nickname, channels.IsWatch(e.Channel))
+				room.SetIdentity(client.UserID(), identity)
+
+				if preferredLanguage != "" && room.GetLanguage(client.UserID()) != preferredLanguage {
+					room.SetLanguage(client.UserID(), preferredLanguage)
+				}
 
-				roomMu.Lock()
 				if channels.IsPlay(e.Channel) {
 					allNicknames := room.GetNicknames()
 					playerNicknames := make(map[string]string)
@@ -173,9 +267,14 @@ func onSubscribe(node *centrifuge.Node, client *centrifuge.Client) func(centrifu
 					Message: map[string]any{"timestamp": room.GetCreatedAt().Format(time.RFC3339)},
 				})
 
+				open := room.IsOpen()
 				roomMu.Unlock()
 				client.Send(roomCreatedAt)
 
+				if open && channels.IsWatch(e.Channel) {
+					client.Send(lobbyLayout(room.GetCode()))
+				}
+
 				newNickname, _ := json.Marshal(response{
 					Type:    "new_nickname",
 					Message: map[string]any{"id": client.UserID(), "nickname": nickname},
@@ -272,6 +371,11 @@ func onMessage(node *centrifuge.Node, client *centrifuge.Client) func(centrifuge
 			return
 		}
 
+		if request.Type == "kick" {
+			kick(node, client, request.Content)
+			return
+		}
+
 		rmManager().Mu.Lock()
 		defer rmManager().Mu.Unlock()
 
@@ -281,33 +385,39 @@ func onMessage(node *centrifuge.Node, client *centrifuge.Client) func(centrifuge
 			room_, roomMu, roomExists := rmManager().Room(roomCode)
 
 			if roomExists {
-				roomMu.RLock()
-				defer roomMu.RUnlock()
+				// Every message changes the room, which the PartyFlow
+				// reads from its own goroutine.
+				roomMu.Lock()
+				defer roomMu.Unlock()
 
 				switch request.Type {
 				case "room_config_changed":
 					if room_.GetOwner() == client.UserID() {
 						options := request.Content["config"].(map[string]any)
+						language, _ := options["language"].(string)
 
 						room_.SetConfig(room.Config{
 							AllowSpectators: options["allowSpectators"].(bool),
 							AllowAnonymous:  options["allowAnonymous"].(bool),
 							AutoStart:       options["autoStart"].(bool),
 							RejectJoins:     !options["allowJoins"].(bool),
+							Language:        language,
 						})
 					}
-				case "kick":
-					playChannel := channels.GetPlayPrefix() + room_.GetCode()
-					watchChannel := channels.GetSpectatePrefix() + room_.GetCode()
+				case "set_language":
+					language, _ := request.Content["language"].(string)
+					err := room_.SetLanguage(client.UserID(), language)
 
-					unsubscribeRequest, _ := json.Marshal(response{
-						Type:    "remove_nickname",
-						Message: map[string]any{"id": request.Content["userID"]},
-					})
+					languageMsg := response{
+						Type:    "language",
+						Message: map[string]any{"language": room_.GetLanguage(client.UserID())},
+					}
+					if err != nil {
+						languageMsg.Message["error"] = err.Error()
+					}
 
-					node.Publish(playChannel, unsubscribeRequest)
-					node.Publish(watchChannel, unsubscribeRequest)
-					node.Unsubscribe(request.Content["userID"].(string), playChannel)
+					data, _ := json.Marshal(languageMsg)
+					client.Send(data)
 				default:
 					room_.AddInput(client.UserID(), room.Input{
 						Type:    request.Type,
@@ -319,9 +429,9 @@ func onMessage(node *centrifuge.Node, client *centrifuge.Client) func(centrifuge
 			room, roomMu, roomExists := rmManager().ByOwner(client.UserID())
 
 			if roomExists {
-				roomMu.RLock()
+				roomMu.Lock()
 				room.Stop()
-				roomMu.RUnlock()
+				roomMu.Unlock()
 			}
 		}
 	}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	migrations "github.com/theWebPartyTime/server/internal/db"
+
+	"github.com/theWebPartyTime/server/internal/config"
+	"github.com/theWebPartyTime/server/internal/models"
+	"github.com/theWebPartyTime/server/internal/repository"
+	"github.com/theWebPartyTime/server/internal/repository/postgres"
+	"github.com/theWebPartyTime/server/internal/simulation"
+)
+
+const usage = `usage:
+	server                     start the server
+	server test <script>...    run the [[tests]] embedded in WebPartySpec files
+	                           and report missing translations
+	server promote <email> <role>
+	                           give the account a role: user, moderator or admin`
+
+func runCommand(args []string) int {
+	switch args[0] {
+	case "test":
+		return testScripts(args[1:])
+	case "promote":
+		return promoteUser(args[1:])
+	default:
+		fmt.Fprintln(os.Stderr, usage)
+		return 2
+	}
+}
+
+func testScripts(paths []string) int {
+	if len(paths) == 0 {
+		fmt.Fprintln(os.Stderr, usage)
+		return 2
+	}
+
+	log.SetOutput(io.Discard)
+	failed := false
+
+	for _, path := range paths {
+		webPartySpec, err := os.ReadFile(path)
+		if err != nil {
+			fmt.Printf("%s: %v\n", path, err)
+			failed = true
+			continue
+		}
+
+		report, err := simulation.RunTests(context.Background(), path, string(webPartySpec))
+		if err != nil {
+			fmt.Printf("%s: %v\n", path, err)
+			failed = true
+			continue
+		}
+
+		for _, missing := range report.MissingTranslations {
+			fmt.Printf("WARN %s: %s\n", path, missing)
+		}
+
+		if len(report.Results) == 0 {
+			fmt.Printf("%s: no tests\n", path)
+		}
+
+		for _, result := range report.Results {
+			if result.Passed {
+				fmt.Printf("PASS %s: %s\n", path, result.Name)
+				continue
+			}
+
+			failed = true
+			fmt.Printf("FAIL %s: %s\n", path, result.Name)
+			for _, diff := range result.Diffs {
+				fmt.Printf("\t- %s\n", diff)
+			}
+		}
+	}
+
+	if failed {
+		return 1
+	}
+
+	return 0
+}
+
+// promoteUser sets a role from the command line, which is how the first
+// admin gets appointed.
+func promoteUser(args []string) int {
+	if len(args) != 2 {
+		fmt.Fprintln(os.Stderr, usage)
+		return 2
+	}
+
+	role := models.Role(args[1])
+	if !role.Valid() {
+		fmt.Fprintf(os.Stderr, "unknown role %q\n", args[1])
+		return 2
+	}
+
+	ctx := context.Background()
+	if err := repository.InitDB(ctx, config.LoadConfig()); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	defer repository.CloseDB()
+
+	if err := migrations.RunMigrations(repository.GetDB()); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	userRepo := postgres.NewPostgresUserRepository(repository.GetDB())
+	user, err := userRepo.GetUserByEmail(ctx, args[0])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "no account with email %s\n", args[0])
+		return 1
+	}
+
+	user.Role = role
+	if err := userRepo.UpdateUser(ctx, user, "role"); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	fmt.Printf("%s is now %s\n", user.Email, role)
+	return 0
+}
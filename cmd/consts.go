@@ -53,3 +53,9 @@ func centrifugeVisibleSubscription() centrifuge.SubscribeOptions {
 		PushJoinLeave: true,
 	}
 }
+
+func sendToUser(node *centrifuge.Node, userID string, data []byte) {
+	for _, client := range node.Hub().UserConnections(userID) {
+		client.Send(data)
+	}
+}
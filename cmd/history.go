@@ -0,0 +1,272 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/theWebPartyTime/server/internal/achievements"
+	"github.com/theWebPartyTime/server/internal/colors"
+	"github.com/theWebPartyTime/server/internal/models"
+	"github.com/theWebPartyTime/server/internal/partyflow"
+	"github.com/theWebPartyTime/server/internal/room"
+	"github.com/theWebPartyTime/server/internal/service"
+)
+
+const historyTimeout = 3 * time.Second
+
+// partyHistory, partyLog, userProfiles and userAchievements are nil until
+// the database is up; nothing is recorded until then.
+var partyHistory *service.PartiesService
+var partyLog *service.EventsService
+var userProfiles *service.ProfilesService
+var userAchievements *service.AchievementsService
+
+// partyRecorder writes the history of the parties of a room one job after
+// the other on its own goroutine, so that the database is never waited on
+// while holding the room locks. The party being played is only touched by
+// the jobs.
+type partyRecorder struct {
+	mu      sync.Mutex
+	jobs    []func()
+	running bool
+	party   *models.Party
+}
+
+// do queues a job, starting a worker if none is running.
+func (recorder *partyRecorder) do(job func()) {
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+
+	recorder.jobs = append(recorder.jobs, job)
+	if !recorder.running {
+		recorder.running = true
+		go recorder.run()
+	}
+}
+
+func (recorder *partyRecorder) run() {
+	for {
+		recorder.mu.Lock()
+		if len(recorder.jobs) == 0 {
+			recorder.running = false
+			recorder.mu.Unlock()
+			return
+		}
+
+		job := recorder.jobs[0]
+		recorder.jobs = recorder.jobs[1:]
+		recorder.mu.Unlock()
+
+		job()
+	}
+}
+
+// recordParty writes a party status change of a room to the history and
+// returns the party being played, if any.
+func recordParty(party *models.Party, status models.PartyStatus, roomCode string, hash string,
+	host int, participants []room.Participant, standings map[string]partyflow.Standing) *models.Party {
+
+	if partyHistory == nil {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), historyTimeout)
+	defer cancel()
+
+	if status == models.PartyActive {
+		party = &models.Party{
+			RoomCode:     roomCode,
+			ScriptHash:   hash,
+			HostId:       accountRef(host),
+			Participants: []models.PartyParticipant{},
+		}
+		addParticipants(party, participants)
+
+		if err := partyHistory.StartParty(ctx, party); err != nil {
+			log.Printf("%v %v", colors.Error("Party history:"), err)
+			return nil
+		}
+
+		return party
+	}
+
+	if party == nil {
+		return nil
+	}
+
+	addParticipants(party, participants)
+
+	wins := make(map[string]int)
+	for user, standing := range standings {
+		wins[user] = standing.WinCount
+	}
+
+	if err := partyHistory.EndParty(ctx, party, status, wins); err != nil {
+		log.Printf("%v %v", colors.Error("Party history:"), err)
+	}
+
+	return nil
+}
+
+// addParticipants adds whoever is not part of the party yet, e.g. players
+// that joined after the start.
+func addParticipants(party *models.Party, participants []room.Participant) {
+	known := make(map[string]bool)
+	for _, participant := range party.Participants {
+		known[participant.SessionId] = true
+	}
+
+	for _, participant := range participants {
+		if known[participant.User] {
+			continue
+		}
+
+		party.Participants = append(party.Participants, models.PartyParticipant{
+			UserId:    accountRef(participant.Account),
+			GuestId:   guestRef(participant.Guest),
+			SessionId: participant.User,
+			Nickname:  participant.Nickname,
+		})
+	}
+}
+
+func accountRef(account int) *int {
+	if account == 0 {
+		return nil
+	}
+
+	return &account
+}
+
+func guestRef(guest string) *string {
+	if guest == "" {
+		return nil
+	}
+
+	return &guest
+}
+
+// logEvent queues a room event to be persisted with the party being played.
+func logEvent(party *models.Party, roomCode string, event room.Event) {
+	if partyLog == nil {
+		return
+	}
+
+	var partyId *int
+	if party != nil {
+		partyId = &party.ID
+	}
+
+	partyLog.Append(models.PartyEvent{
+		RoomCode:   roomCode,
+		PartyId:    partyId,
+		Seq:        event.Seq,
+		Type:       event.Type,
+		SessionId:  event.User,
+		Data:       event.Data,
+		OccurredAt: event.At,
+	})
+}
+
+// recordAnswers stores what every participant answered to a judged step.
+// It is meant to run on a partyRecorder, with the inputs copied before the
+// room clears them.
+func recordAnswers(party *models.Party, partyQuery *partyflow.PartyQuery, queriedAt time.Time,
+	participants []room.Participant, inputs map[string]room.Input, winners []string) {
+
+	if partyHistory == nil || party == nil || partyQuery.Input == nil {
+		return
+	}
+
+	messages := room.Messages(inputs)
+	answers := make([]models.PartyAnswer, 0, len(participants))
+
+	for _, participant := range participants {
+		answer := models.PartyAnswer{
+			PartyId:   party.ID,
+			SessionId: participant.User,
+			UserId:    accountRef(participant.Account),
+			GuestId:   guestRef(participant.Guest),
+			Nickname:  participant.Nickname,
+			Step:      partyQuery.Step,
+			Query:     partyQuery.Name,
+		}
+
+		if message, ok := messages[participant.User]; ok {
+			responseMs := inputs[participant.User].ReceivedAt.Sub(queriedAt).Milliseconds()
+			answer.Answer = message
+			answer.Answered = true
+			answer.ResponseMs = &responseMs
+		}
+
+		if slices.Contains(winners, participant.User) {
+			answer.Correct = true
+			answer.Points = 1
+		}
+
+		answers = append(answers, answer)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), historyTimeout)
+	defer cancel()
+
+	if err := partyHistory.RecordAnswers(ctx, answers); err != nil {
+		log.Printf("%v %v", colors.Error("Party history:"), err)
+	}
+}
+
+// awardAchievements checks the achievement rules for the host and every
+// registered player of a party that just ended, and privately notifies
+// whoever earned new ones. It is meant to run on a partyRecorder, with the
+// progress of the players copied before the tracker is reset.
+func awardAchievements(party *models.Party, status models.PartyStatus,
+	progress map[string]achievements.PartyProgress, hostSession string, sendToUser func(string, []byte)) {
+
+	if userAchievements == nil || party == nil {
+		return
+	}
+
+	type earner struct {
+		session  string
+		account  int
+		progress achievements.PartyProgress
+	}
+
+	earners := []earner{}
+	if party.HostId != nil {
+		earners = append(earners, earner{session: hostSession, account: *party.HostId})
+	}
+
+	for _, participant := range party.Participants {
+		if participant.UserId != nil {
+			earners = append(earners, earner{session: participant.SessionId, account: *participant.UserId,
+				progress: progress[participant.SessionId]})
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), historyTimeout)
+	defer cancel()
+
+	for _, earner := range earners {
+		earned, err := userAchievements.Award(ctx, earner.account, party.ID,
+			earner.progress, status == models.PartyFinished)
+		if err != nil {
+			log.Printf("%v %v", colors.Error("Achievements:"), err)
+			continue
+		}
+
+		if len(earned) == 0 {
+			continue
+		}
+
+		data, _ := json.Marshal(response{
+			Type:    "achievements",
+			Message: map[string]any{"achievements": earned},
+		})
+		sendToUser(earner.session, data)
+	}
+}
@@ -0,0 +1,214 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+	"time"
+
+	"github.com/centrifugal/centrifuge"
+	"github.com/theWebPartyTime/server/internal/auth"
+	"github.com/theWebPartyTime/server/internal/ratelimit"
+)
+
+const (
+	defaultInviteTTL = 24 * time.Hour
+	maxInviteTTL     = 7 * 24 * time.Hour
+	// joinLimiterTimeout bounds how long the join limiter is waited on,
+	// since it is asked while holding the room locks. It fails open.
+	joinLimiterTimeout = 500 * time.Millisecond
+)
+
+// pinFailures stops a room from taking PINs for a while after 20 wrong ones,
+// so its PIN can not be guessed; invites still get in.
+var pinFailures = ratelimit.Limit{Burst: 20, Period: 10 * time.Minute}
+
+// inviteKeys, joinLimiter and appURL are set once the server is configured;
+// until then no invites are handed out and PINs are checked without limits.
+var inviteKeys *auth.KeySet
+var joinLimiter *ratelimit.Limiter
+var appURL string
+
+type joinRequest struct {
+	Nickname string `json:"nickname"`
+	Pin      string `json:"pin"`
+	Invite   string `json:"invite"`
+}
+
+// parseJoinRequest reads the data of a room subscription: either a bare
+// nickname or an object also carrying a PIN or an invite.
+func parseJoinRequest(data []byte) joinRequest {
+	var join joinRequest
+	if err := json.Unmarshal(data, &join.Nickname); err != nil {
+		json.Unmarshal(data, &join)
+	}
+
+	return join
+}
+
+type inviteRequest struct {
+	Role      string `json:"role"`
+	ExpiresIn int    `json:"expiresIn"`
+}
+
+type pinRequest struct {
+	Pin string `json:"pin"`
+}
+
+// joinableRoom is what admit needs to know of a room.
+type joinableRoom interface {
+	GetOwner() string
+	GetCode() string
+	GetCreatedAt() time.Time
+	HasPin() bool
+	CheckPin(pin string) bool
+}
+
+// admit checks the PIN or invite a user joins a room with. The owner needs
+// neither; an invite skips the PIN but only lets in under its role.
+func admit(room_ joinableRoom, user string, join joinRequest, spectatorMode bool) *centrifuge.Error {
+	if room_.GetOwner() == user {
+		return nil
+	}
+
+	if join.Invite != "" {
+		if inviteKeys == nil {
+			return &centrifuge.Error{Code: 403, Message: "Invites are unavailable."}
+		}
+
+		invite, err := inviteKeys.ParseInviteToken(join.Invite)
+		if err != nil || invite.Room != room_.GetCode() || invite.CreatedAt.Unix() != room_.GetCreatedAt().Unix() {
+			return &centrifuge.Error{Code: 403, Message: "The invite is invalid or has expired."}
+		}
+
+		if (invite.Role == auth.InviteSpectator) != spectatorMode {
+			return &centrifuge.Error{Code: 403, Message: "The invite is for " + invite.Role + "s only."}
+		}
+
+		return nil
+	}
+
+	if !room_.HasPin() {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), joinLimiterTimeout)
+	defer cancel()
+
+	key := "pin:room:" + room_.GetCode()
+	if joinLimiter != nil {
+		if result := joinLimiter.Check(ctx, key, pinFailures); !result.Allowed {
+			return &centrifuge.Error{Code: 429, Message: "Too many wrong PINs, ask the host for an invite."}
+		}
+	}
+
+	if join.Pin == "" {
+		return &centrifuge.Error{Code: 403, Message: "A PIN is required to join this room."}
+	}
+
+	if !room_.CheckPin(join.Pin) {
+		if joinLimiter != nil {
+			joinLimiter.Take(ctx, key, pinFailures)
+		}
+		return &centrifuge.Error{Code: 403, Message: "The PIN is wrong."}
+	}
+
+	return nil
+}
+
+// createInvite signs an invite to the room owned by owner and returns it
+// along with the link to hand out, when APP_URL is set.
+func createInvite(owner string, data []byte) ([]byte, *centrifuge.Error) {
+	var request inviteRequest
+	if err := json.Unmarshal(data, &request); err != nil {
+		return nil, &centrifuge.Error{Code: 400, Message: "Data provided to the remote procedure is invalid."}
+	}
+
+	if request.Role == "" {
+		request.Role = auth.InvitePlayer
+	}
+	if request.Role != auth.InvitePlayer && request.Role != auth.InviteSpectator {
+		return nil, &centrifuge.Error{Code: 400, Message: "Invites are either for players or for spectators."}
+	}
+
+	ttl := defaultInviteTTL
+	if request.ExpiresIn > 0 {
+		ttl = min(time.Duration(request.ExpiresIn)*time.Second, maxInviteTTL)
+	}
+
+	if inviteKeys == nil {
+		return nil, &centrifuge.Error{Code: 503, Message: "Invites are unavailable."}
+	}
+
+	rmManager().Mu.Lock()
+	room_, roomMu, roomFound := rmManager().ByOwner(owner)
+	if !roomFound {
+		rmManager().Mu.Unlock()
+		return nil, &centrifuge.Error{Code: 400, Message: "User does not own any room."}
+	}
+
+	roomMu.RLock()
+	invite := auth.Invite{Room: room_.GetCode(), CreatedAt: room_.GetCreatedAt(), Role: request.Role}
+	roomMu.RUnlock()
+	rmManager().Mu.Unlock()
+
+	token, err := inviteKeys.InviteToken(invite, ttl)
+	if err != nil {
+		return nil, &centrifuge.Error{Code: 500, Message: err.Error()}
+	}
+
+	response := map[string]any{
+		"token":     token,
+		"role":      invite.Role,
+		"expiresAt": time.Now().Add(ttl).Format(time.RFC3339),
+	}
+	if appURL != "" {
+		response["link"] = joinLink(invite.Room, token)
+	}
+
+	encoded, _ := json.Marshal(response)
+	return encoded, nil
+}
+
+// setRoomPin sets or, with an empty PIN, drops the PIN of the room owned by
+// owner.
+func setRoomPin(owner string, data []byte) *centrifuge.Error {
+	var request pinRequest
+	if err := json.Unmarshal(data, &request); err != nil {
+		return &centrifuge.Error{Code: 400, Message: "Data provided to the remote procedure is invalid."}
+	}
+
+	rmManager().Mu.Lock()
+	defer rmManager().Mu.Unlock()
+
+	room_, roomMu, roomFound := rmManager().ByOwner(owner)
+	if !roomFound {
+		return &centrifuge.Error{Code: 400, Message: "User does not own any room."}
+	}
+
+	roomMu.Lock()
+	defer roomMu.Unlock()
+
+	if err := room_.SetPin(request.Pin); err != nil {
+		return &centrifuge.Error{Code: 400, Message: err.Error()}
+	}
+
+	if joinLimiter != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), joinLimiterTimeout)
+		defer cancel()
+
+		joinLimiter.Reset(ctx, "pin:room:"+room_.GetCode())
+	}
+
+	return nil
+}
+
+// joinLink is where the app lets people join a room, with an invite if any.
+func joinLink(roomCode string, invite string) string {
+	link := appURL + "/room/" + url.PathEscape(roomCode)
+	if invite != "" {
+		link += "?invite=" + url.QueryEscape(invite)
+	}
+
+	return link
+}
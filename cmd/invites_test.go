@@ -0,0 +1,75 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/theWebPartyTime/server/internal/auth"
+	"github.com/theWebPartyTime/server/internal/ratelimit"
+)
+
+type fakeRoom struct {
+	pin       string
+	createdAt time.Time
+}
+
+func (room *fakeRoom) GetOwner() string         { return "owner" }
+func (room *fakeRoom) GetCode() string          { return "ABCDEFGHI" }
+func (room *fakeRoom) GetCreatedAt() time.Time  { return room.createdAt }
+func (room *fakeRoom) HasPin() bool             { return room.pin != "" }
+func (room *fakeRoom) CheckPin(pin string) bool { return room.pin == "" || room.pin == pin }
+
+func TestAdmit(t *testing.T) {
+	key, err := auth.GenerateKey("invites")
+	if err != nil {
+		t.Fatal(err)
+	}
+	inviteKeys, joinLimiter = auth.NewKeySet(key), ratelimit.NewLimiter(ratelimit.NewMemoryStore())
+	defer func() { inviteKeys, joinLimiter = nil, nil }()
+
+	room := &fakeRoom{createdAt: time.Unix(1700000000, 0)}
+	if err := admit(room, "player", joinRequest{}, false); err != nil {
+		t.Errorf("room without a PIN: %v", err)
+	}
+
+	room.pin = "2468"
+	invite := func(role string, createdAt time.Time) string {
+		token, _ := inviteKeys.InviteToken(auth.Invite{Room: room.GetCode(), CreatedAt: createdAt, Role: role}, time.Hour)
+		return token
+	}
+
+	cases := []struct {
+		name      string
+		user      string
+		join      joinRequest
+		spectator bool
+		code      uint32
+	}{
+		{"owner", "owner", joinRequest{}, false, 0},
+		{"no PIN", "player", joinRequest{}, false, 403},
+		{"wrong PIN", "player", joinRequest{Pin: "1357"}, false, 403},
+		{"right PIN", "player", joinRequest{Pin: "2468"}, false, 0},
+		{"player invite", "player", joinRequest{Invite: invite(auth.InvitePlayer, room.createdAt)}, false, 0},
+		{"spectator invite to play", "player", joinRequest{Invite: invite(auth.InviteSpectator, room.createdAt)}, false, 403},
+		{"spectator invite", "player", joinRequest{Invite: invite(auth.InviteSpectator, room.createdAt)}, true, 0},
+		{"invite to an earlier room", "player", joinRequest{Invite: invite(auth.InvitePlayer, room.createdAt.Add(-time.Hour))}, false, 403},
+		{"garbage invite", "player", joinRequest{Invite: "garbage", Pin: "2468"}, false, 403},
+	}
+
+	for _, c := range cases {
+		err := admit(room, c.user, c.join, c.spectator)
+		if (err == nil && c.code != 0) || (err != nil && err.Code != c.code) {
+			t.Errorf("%s: admit = %v, want code %d", c.name, err, c.code)
+		}
+	}
+
+	for range pinFailures.Burst {
+		admit(room, "player", joinRequest{Pin: "0000"}, false)
+	}
+	if err := admit(room, "player", joinRequest{Pin: "2468"}, false); err == nil || err.Code != 429 {
+		t.Errorf("after too many wrong PINs: admit = %v, want code 429", err)
+	}
+	if err := admit(room, "player", joinRequest{Invite: invite(auth.InvitePlayer, room.createdAt)}, false); err != nil {
+		t.Errorf("invites should still get in: %v", err)
+	}
+}
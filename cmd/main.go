@@ -7,10 +7,16 @@ import (
 
 	GinAuthMiddleware "github.com/theWebPartyTime/server/internal/auth"
 	migrations "github.com/theWebPartyTime/server/internal/db"
+	fileMailer "github.com/theWebPartyTime/server/internal/mailer/file"
+	smtpMailer "github.com/theWebPartyTime/server/internal/mailer/smtp"
 	localStorage "github.com/theWebPartyTime/server/internal/storage/local"
 
 	"github.com/theWebPartyTime/server/internal/config"
 	"github.com/theWebPartyTime/server/internal/handlers"
+	"github.com/theWebPartyTime/server/internal/mailer"
+	"github.com/theWebPartyTime/server/internal/models"
+	"github.com/theWebPartyTime/server/internal/oidc"
+	"github.com/theWebPartyTime/server/internal/ratelimit"
 	"github.com/theWebPartyTime/server/internal/repository"
 	"github.com/theWebPartyTime/server/internal/repository/postgres"
 	"github.com/theWebPartyTime/server/internal/service"
@@ -20,16 +26,46 @@ import (
 	"github.com/centrifugal/centrifuge"
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
+	"github.com/redis/rueidis"
 	"gorm.io/gorm"
 )
 
 func main() {
+	if len(os.Args) > 1 {
+		os.Exit(runCommand(os.Args[1:]))
+	}
+
 	dir, _ := os.Getwd()
-	log.Printf(dir)
+	log.Print(dir)
 
 	router := gin.Default()
 	router.SetTrustedProxies(nil)
 
+	ctx := context.Background()
+	config := config.LoadConfig()
+
+	err := repository.InitDB(ctx, config)
+	if err != nil {
+		log.Fatal("Failed to initialize database:", err)
+	}
+
+	db := repository.GetDB()
+
+	if err := migrations.RunMigrations(db); err != nil {
+		log.Fatal("Failed to run migrations:", err)
+	}
+	defer repository.CloseDB()
+
+	deps := NewDependencies(db, config)
+	limiter := deps.NewLimiter()
+	keys := deps.NewKeySet()
+	inviteKeys, joinLimiter, appURL = keys, limiter, config.AppURL
+	authHandler := deps.NewAuthHandler(limiter, keys)
+	scriptsHandler := deps.NewScriptsHandler()
+	accessTokensService := deps.NewAccessTokensService()
+	accessTokensHandler := handlers.NewAccessTokensHandler(accessTokensService)
+	authMiddleware := deps.NewAuthMiddleware(keys, accessTokensService)
+
 	node, err := centrifuge.New(centrifugeMainConfig())
 
 	if err != nil {
@@ -43,36 +79,30 @@ func main() {
 		client.OnUnsubscribe(onUnsubscribe(node, client))
 		client.OnDisconnect(onDisconnect(client))
 		client.OnMessage(onMessage(node, client))
+		client.OnRefresh(authMiddleware.RefreshConnection(client))
 	})
 
 	if err := node.Run(); err != nil {
 		log.Fatal(err)
 	}
 
-	ctx := context.Background()
-	config := config.LoadConfig()
-
-	err = repository.InitDB(ctx, config)
-	if err != nil {
-		log.Fatal("Failed to initialize database:", err)
-	}
-
-	db := repository.GetDB()
+	partyHistory = deps.NewPartiesService()
+	partyLog = deps.NewEventsService(partyHistory)
+	partiesHandler := handlers.NewPartiesHandler(partyHistory, partyLog)
+	userProfiles = deps.NewProfilesService()
+	userAchievements = deps.NewAchievementsService()
+	profilesHandler := handlers.NewProfilesHandler(userProfiles, userAchievements, deps.NewAccountDataService(userProfiles))
+	imageHandler := deps.NewImageHandler()
+	leaderboardsHandler := deps.NewLeaderboardsHandler()
+	adminHandler := deps.NewAdminHandler()
 
-	if err := migrations.RunMigrations(db); err != nil {
-		log.Fatal("Failed to run migrations:", err)
-	}
-	defer repository.CloseDB()
+	go partyLog.Run(ctx)
 
 	wsHandler := centrifuge.NewWebsocketHandler(node, wsMainConfig())
 	router.GET("/", root)
+	router.GET("/.well-known/jwks.json", authHandler.JWKS)
 	router.GET(socketPath,
-		gin.WrapH(GinAuthMiddleware.CentrifugeAuthMiddleware(wsHandler)))
-
-	deps := NewDependencies(db, config)
-	authHandler := deps.NewAuthHandler()
-	scriptsHandler := deps.NewScriptsHandler()
-	authMiddleware := deps.NewAuthMiddleware()
+		gin.WrapH(authMiddleware.CentrifugeAuthMiddleware(wsHandler)))
 
 	router.Use(corsMiddleware())
 	router.OPTIONS("/*path", func(c *gin.Context) {
@@ -81,16 +111,85 @@ func main() {
 
 	authGroup := router.Group("/auth")
 
-	authGroup.POST("/login", authHandler.Login)
-	authGroup.POST("/register", authHandler.Register)
-	authGroup.POST("/refresh", authHandler.RefreshToken)
-
-	scriptsGroup := router.Group("/scripts", authMiddleware.GinAuthMiddleware())
+	perMinute := ratelimit.Limit{Burst: 10, Period: time.Minute}
+	perHour := ratelimit.Limit{Burst: 5, Period: time.Hour}
+	perQuarter := ratelimit.Limit{Burst: 10, Period: 15 * time.Minute}
+
+	authGroup.POST("/login", limiter.ByIP("login", perMinute), authHandler.Login)
+	authGroup.POST("/register", limiter.ByIP("register", perHour), authHandler.Register)
+	authGroup.POST("/refresh", limiter.ByIP("refresh", perMinute), authHandler.RefreshToken)
+	authGroup.POST("/logout", authHandler.Logout)
+	authGroup.POST("/password/forgot", limiter.ByIP("password", perQuarter), authHandler.ForgotPassword)
+	authGroup.POST("/password/reset", limiter.ByIP("password", perQuarter), authHandler.ResetPassword)
+	authGroup.POST("/email/verify", limiter.ByIP("email", perQuarter), authHandler.VerifyEmail)
+	authGroup.POST("/email/resend", limiter.ByIP("email", perQuarter),
+		authMiddleware.GinAuthMiddleware(), authHandler.ResendVerification)
+	authGroup.POST("/mfa", limiter.ByIP("login", perMinute), authHandler.MFA)
+	authGroup.POST("/guest", limiter.ByIP("guest", perMinute), authHandler.Guest)
+	authGroup.GET("/oidc/:provider/start", limiter.ByIP("oidc", perMinute), authHandler.OIDCStart)
+	authGroup.GET("/oidc/:provider/callback", limiter.ByIP("oidc", perMinute), authHandler.OIDCCallback)
+
+	sessionsGroup := authGroup.Group("/sessions", authMiddleware.GinAuthMiddleware())
+
+	sessionsGroup.GET("/", authHandler.Sessions)
+	sessionsGroup.DELETE("/", authHandler.RevokeOtherSessions)
+	sessionsGroup.DELETE("/:session_id", authHandler.RevokeSession)
+
+	scriptsGroup := router.Group("/scripts",
+		authMiddleware.GinAuthMiddleware(models.ScopeScriptsRead, models.ScopeScriptsWrite))
+	writeScripts := GinAuthMiddleware.RequireScope(models.ScopeScriptsWrite)
 
 	scriptsGroup.GET("/user", scriptsHandler.UserScripts)
 	scriptsGroup.GET("/public", scriptsHandler.PublicScripts)
-	scriptsGroup.POST("/", scriptsHandler.UploadScript)
-	scriptsGroup.PUT("/:script_hash", scriptsHandler.UpdateScript)
+	scriptsGroup.POST("/", writeScripts, scriptsHandler.UploadScript)
+	scriptsGroup.PUT("/:script_hash", writeScripts, scriptsHandler.UpdateScript)
+	scriptsGroup.POST("/:script_hash/simulate", scriptsHandler.SimulateScript)
+	scriptsGroup.POST("/:script_hash/test", scriptsHandler.TestScript)
+	scriptsGroup.GET("/:script_hash/analytics", partiesHandler.ScriptAnalytics)
+	scriptsGroup.GET("/:script_hash/leaderboard", leaderboardsHandler.Leaderboard)
+	scriptsGroup.DELETE("/:script_hash/leaderboard/:user_id", writeScripts, leaderboardsHandler.ResetPlayer)
+	scriptsGroup.POST("/:script_hash/leaderboard/exclusions/:user_id", writeScripts, leaderboardsHandler.ExcludePlayer)
+	scriptsGroup.DELETE("/:script_hash/leaderboard/exclusions/:user_id", writeScripts, leaderboardsHandler.IncludePlayer)
+
+	router.GET("/images/:hash", imageHandler.GetMediaByHash)
+	router.GET("/rooms/:room_code/qr.png", limiter.ByIP("qr", perMinute), roomQR("png"))
+	router.GET("/rooms/:room_code/qr.svg", limiter.ByIP("qr", perMinute), roomQR("svg"))
+	router.GET("/users/:user_id/profile", profilesHandler.Profile)
+	router.GET("/users/:user_id/achievements", profilesHandler.Achievements)
+
+	usersGroup := router.Group("/users", authMiddleware.GinAuthMiddleware())
+
+	usersGroup.GET("/me", profilesHandler.Me)
+	usersGroup.PATCH("/me", profilesHandler.UpdateMe)
+	usersGroup.DELETE("/me", limiter.ByIP("password", perQuarter), profilesHandler.DeleteMe)
+	usersGroup.GET("/me/export", limiter.ByIP("export", perMinute), profilesHandler.Export)
+	usersGroup.POST("/me/mfa", authHandler.EnrollMFA)
+	usersGroup.POST("/me/mfa/verify", authHandler.ConfirmMFA)
+	usersGroup.DELETE("/me/mfa", authHandler.DisableMFA)
+	usersGroup.GET("/me/tokens", accessTokensHandler.Tokens)
+	usersGroup.POST("/me/tokens", accessTokensHandler.CreateToken)
+	usersGroup.DELETE("/me/tokens/:token_id", accessTokensHandler.RevokeToken)
+
+	partiesGroup := router.Group("/parties", authMiddleware.GinAuthMiddleware())
+
+	partiesGroup.GET("/", partiesHandler.UserParties)
+	partiesGroup.GET("/:party_id", partiesHandler.Party)
+	partiesGroup.GET("/:party_id/events", partiesHandler.PartyEvents)
+	partiesGroup.GET("/:party_id/results", partiesHandler.PartyResults)
+
+	moderators := GinAuthMiddleware.RequireRole(models.RoleModerator, models.RoleAdmin)
+	admins := GinAuthMiddleware.RequireRole(models.RoleAdmin)
+
+	adminGroup := router.Group("/admin", authMiddleware.GinAuthMiddleware(), moderators)
+
+	adminGroup.GET("/users", adminHandler.Users)
+	adminGroup.POST("/users/:user_id/ban", adminHandler.BanUser)
+	adminGroup.DELETE("/users/:user_id/ban", adminHandler.UnbanUser)
+	adminGroup.PUT("/users/:user_id/role", admins, adminHandler.SetRole)
+	adminGroup.POST("/scripts/:script_hash/unpublish", adminHandler.UnpublishScript)
+	adminGroup.DELETE("/scripts/:script_hash", admins, adminHandler.DeleteScript)
+	adminGroup.GET("/rooms", listRooms)
+	adminGroup.DELETE("/rooms/:room_code", closeRoom(node))
 
 	router.Run("0.0.0.0:8080")
 }
@@ -107,29 +206,168 @@ func NewDependencies(db *gorm.DB, config config.Config) *Dependencies {
 	}
 }
 
-func (d *Dependencies) NewAuthHandler() *handlers.AuthHandler {
+func (d *Dependencies) NewAuthHandler(limiter *ratelimit.Limiter, keys *GinAuthMiddleware.KeySet) *handlers.AuthHandler {
 	userRepo := postgres.NewPostgresUserRepository(d.db)
+	sessionsRepo := postgres.NewPostgresSessionsRepository(d.db)
 	authService := service.NewAuthService(userRepo)
-	return handlers.NewAuthHandler(authService, []byte(d.config.JWTSecret))
+	tokensRepo := postgres.NewPostgresAccountTokensRepository(d.db)
+	sessionsService := d.NewSessionsService()
+	identitiesRepo := postgres.NewPostgresIdentitiesRepository(d.db)
+	accountService := service.NewAccountService(userRepo, tokensRepo, sessionsRepo, d.NewMailer(), d.config.AppURL)
+	oidcService := service.NewOIDCService(d.NewOIDCProviders(), userRepo, identitiesRepo, sessionsRepo)
+	mfaService := service.NewMFAService(userRepo, postgres.NewPostgresMFARepository(d.db))
+	guestsService := service.NewGuestsService(postgres.NewPostgresGuestsRepository(d.db))
+	return handlers.NewAuthHandler(authService, sessionsService, accountService, oidcService, mfaService, guestsService, limiter, keys)
 
 }
 
+func (d *Dependencies) NewSessionsService() *service.SessionsService {
+	userRepo := postgres.NewPostgresUserRepository(d.db)
+	sessionsRepo := postgres.NewPostgresSessionsRepository(d.db)
+	return service.NewSessionsService(sessionsRepo, userRepo, 7*24*time.Hour)
+}
+
 func (d *Dependencies) NewScriptsHandler() *handlers.ScriptsHandler {
 	scriptsRepo := postgres.NewPostgresScriptsRepository(d.db)
+	userRepo := postgres.NewPostgresUserRepository(d.db)
 	scriptsStorage := localStorage.NewLocalFilesStorage("/app/uploads/scripts/", ".toml")
 	imagesStorage := localStorage.NewLocalFilesStorage("/app/uploads/images/", ".jpg")
-	scriptsService := service.NewScriptsService(scriptsRepo, scriptsStorage, imagesStorage)
+	scriptsService := service.NewScriptsService(scriptsRepo, userRepo, scriptsStorage, imagesStorage)
 	return handlers.NewScriptsHandler(scriptsService)
 }
 
+func (d *Dependencies) NewAdminHandler() *handlers.AdminHandler {
+	userRepo := postgres.NewPostgresUserRepository(d.db)
+	sessionsRepo := postgres.NewPostgresSessionsRepository(d.db)
+	scriptsRepo := postgres.NewPostgresScriptsRepository(d.db)
+	scriptsStorage := localStorage.NewLocalFilesStorage("/app/uploads/scripts/", ".toml")
+	imagesStorage := localStorage.NewLocalFilesStorage("/app/uploads/images/", ".jpg")
+	scriptsService := service.NewScriptsService(scriptsRepo, userRepo, scriptsStorage, imagesStorage)
+	return handlers.NewAdminHandler(service.NewAdminService(userRepo, sessionsRepo, scriptsRepo, scriptsService))
+}
+
+func (d *Dependencies) NewPartiesService() *service.PartiesService {
+	partiesRepo := postgres.NewPostgresPartiesRepository(d.db)
+	scriptsRepo := postgres.NewPostgresScriptsRepository(d.db)
+	leaderboardsRepo := postgres.NewPostgresLeaderboardsRepository(d.db)
+	return service.NewPartiesService(partiesRepo, scriptsRepo, leaderboardsRepo)
+}
+
+func (d *Dependencies) NewLeaderboardsHandler() *handlers.LeaderboardsHandler {
+	leaderboardsRepo := postgres.NewPostgresLeaderboardsRepository(d.db)
+	scriptsRepo := postgres.NewPostgresScriptsRepository(d.db)
+	return handlers.NewLeaderboardsHandler(service.NewLeaderboardsService(leaderboardsRepo, scriptsRepo))
+}
+
+func (d *Dependencies) NewEventsService(partiesService *service.PartiesService) *service.EventsService {
+	eventsRepo := postgres.NewPostgresEventsRepository(d.db)
+	return service.NewEventsService(eventsRepo, partiesService)
+}
+
+func (d *Dependencies) NewProfilesService() *service.ProfilesService {
+	userRepo := postgres.NewPostgresUserRepository(d.db)
+	partiesRepo := postgres.NewPostgresPartiesRepository(d.db)
+	filesRepo := postgres.NewPostgresFilesRepository(d.db)
+	imagesStorage := localStorage.NewLocalFilesStorage("/app/uploads/images/", ".jpg")
+	return service.NewProfilesService(userRepo, partiesRepo, filesRepo, imagesStorage)
+}
+
+func (d *Dependencies) NewAccountDataService(profilesService *service.ProfilesService) *service.AccountDataService {
+	accountDataRepo := postgres.NewPostgresAccountDataRepository(d.db)
+	userRepo := postgres.NewPostgresUserRepository(d.db)
+	scriptsStorage := localStorage.NewLocalFilesStorage("/app/uploads/scripts/", ".toml")
+	imagesStorage := localStorage.NewLocalFilesStorage("/app/uploads/images/", ".jpg")
+	filesRepo := postgres.NewPostgresFilesRepository(d.db)
+	sessionsRepo := postgres.NewPostgresSessionsRepository(d.db)
+	mfaService := service.NewMFAService(userRepo, postgres.NewPostgresMFARepository(d.db))
+	return service.NewAccountDataService(accountDataRepo, userRepo, filesRepo, sessionsRepo, mfaService,
+		d.NewMailer(), profilesService, scriptsStorage, imagesStorage)
+}
+
+func (d *Dependencies) NewAchievementsService() *service.AchievementsService {
+	achievementsRepo := postgres.NewPostgresAchievementsRepository(d.db)
+	partiesRepo := postgres.NewPostgresPartiesRepository(d.db)
+	return service.NewAchievementsService(achievementsRepo, partiesRepo)
+}
+
 func (d *Dependencies) NewImageHandler() *handlers.AssetsHandler {
 	contentType := "image/jpg"
 	imageStorage := localStorage.NewLocalFilesStorage("/app/uploads/images/", ".jpg")
 	return handlers.NewAssetsHandler(imageStorage, contentType)
 }
 
-func (d *Dependencies) NewAuthMiddleware() *GinAuthMiddleware.JWTMiddleware {
-	return GinAuthMiddleware.NewJWTMiddleware([]byte(d.config.JWTSecret))
+func (d *Dependencies) NewMailer() mailer.Mailer {
+	if d.config.Mail.Host == "" {
+		return fileMailer.NewFileMailer(d.config.Mail.Dir)
+	}
+
+	return smtpMailer.NewSMTPMailer(d.config.Mail.Host, d.config.Mail.Port,
+		d.config.Mail.Username, d.config.Mail.Password, d.config.Mail.From)
+}
+
+func (d *Dependencies) NewOIDCProviders() []*oidc.Provider {
+	var providers []*oidc.Provider
+	for _, provider := range d.config.OIDC {
+		providers = append(providers, oidc.NewProvider(oidc.Config{
+			Name:         provider.Name,
+			Issuer:       provider.Issuer,
+			ClientID:     provider.ClientID,
+			ClientSecret: provider.ClientSecret,
+			RedirectURL:  provider.RedirectURL,
+		}))
+	}
+	return providers
+}
+
+func (d *Dependencies) NewLimiter() *ratelimit.Limiter {
+	if d.config.RedisURL == "" {
+		return ratelimit.NewLimiter(ratelimit.NewMemoryStore())
+	}
+
+	option, err := rueidis.ParseURL(d.config.RedisURL)
+	if err != nil {
+		log.Fatal("Invalid REDIS_URL:", err)
+	}
+	client, err := rueidis.NewClient(option)
+	if err != nil {
+		log.Fatal("Failed to connect to redis:", err)
+	}
+
+	return ratelimit.NewLimiter(ratelimit.NewRedisStore(client, "ratelimit:"))
+}
+
+func (d *Dependencies) NewAccessTokensService() *service.AccessTokensService {
+	tokensRepo := postgres.NewPostgresAccessTokensRepository(d.db)
+	userRepo := postgres.NewPostgresUserRepository(d.db)
+	return service.NewAccessTokensService(tokensRepo, userRepo)
+}
+
+// NewKeySet loads the JWT signing keys. Deployments that only configure the
+// former JWT_SECRET keep signing with it; one of the two is required.
+func (d *Dependencies) NewKeySet() *GinAuthMiddleware.KeySet {
+	var secret []*GinAuthMiddleware.SigningKey
+	if d.config.JWTSecret != "" {
+		secret = append(secret, GinAuthMiddleware.SecretKey([]byte(d.config.JWTSecret)))
+	}
+
+	if d.config.JWTKeysDir == "" {
+		if len(secret) == 0 {
+			log.Fatal("Either JWT_KEYS_DIR or JWT_SECRET has to be set")
+		}
+		log.Println("WARNING: JWT_KEYS_DIR is not set, signing tokens with JWT_SECRET")
+		return GinAuthMiddleware.NewKeySet(secret[0])
+	}
+
+	keys, err := GinAuthMiddleware.LoadKeySet(d.config.JWTKeysDir, d.config.JWTActiveKey, secret...)
+	if err != nil {
+		log.Fatal("Failed to load signing keys:", err)
+	}
+	return keys
+}
+
+func (d *Dependencies) NewAuthMiddleware(keys *GinAuthMiddleware.KeySet, tokens GinAuthMiddleware.TokenVerifier) *GinAuthMiddleware.JWTMiddleware {
+	userRepo := postgres.NewPostgresUserRepository(d.db)
+	return GinAuthMiddleware.NewJWTMiddleware(keys, tokens, d.NewSessionsService(), userRepo)
 }
 
 func corsMiddleware() gin.HandlerFunc {
@@ -149,6 +387,7 @@ func corsMiddleware() gin.HandlerFunc {
 			"Origin",
 			"Content-Type",
 			"Authorization",
+			"X-Guest-Token",
 		},
 		AllowCredentials: true,
 
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/theWebPartyTime/server/internal/qrcode"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultQRSize = 256
+	minQRSize     = 64
+	maxQRSize     = 1024
+)
+
+// roomQR serves a QR code of the join link of a room, as a PNG or an SVG
+// image. An invite to the room passed along ends up in the link. Without
+// APP_URL the link would be relative, which no phone can open.
+func roomQR(format string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if appURL == "" {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "QR codes are unavailable"})
+			return
+		}
+
+		roomCode := c.Param("room_code")
+
+		size := defaultQRSize
+		if sizeStr := c.Query("size"); sizeStr != "" {
+			parsed, err := strconv.Atoi(sizeStr)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "invalid size"})
+				return
+			}
+			size = min(max(parsed, minQRSize), maxQRSize)
+		}
+
+		rmManager().Mu.RLock()
+		room, roomMu, roomExists := rmManager().Room(roomCode)
+		if !roomExists {
+			rmManager().Mu.RUnlock()
+			c.JSON(http.StatusNotFound, gin.H{"error": "room not found"})
+			return
+		}
+
+		roomMu.RLock()
+		createdAt := room.GetCreatedAt()
+		roomMu.RUnlock()
+		rmManager().Mu.RUnlock()
+
+		invite := c.Query("invite")
+		if invite != "" {
+			if inviteKeys == nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "invalid invite"})
+				return
+			}
+
+			parsed, err := inviteKeys.ParseInviteToken(invite)
+			if err != nil || parsed.Room != roomCode || parsed.CreatedAt.Unix() != createdAt.Unix() {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "invalid invite"})
+				return
+			}
+		}
+
+		link := joinLink(roomCode, invite)
+
+		var image []byte
+		var err error
+		contentType := "image/png"
+		if format == "svg" {
+			image, err = qrcode.SVG(link, size)
+			contentType = "image/svg+xml"
+		} else {
+			image, err = qrcode.PNG(link, size)
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.Header("Cache-Control", "private, max-age=300")
+		c.Data(http.StatusOK, contentType, image)
+	}
+}
+
+// lobbyLayout is what spectators of an open room are shown: how to join it.
+// Without APP_URL there is no link to show, only the room code.
+func lobbyLayout(roomCode string) []byte {
+	layout := map[string]any{
+		"type": "lobby",
+		"code": roomCode,
+	}
+
+	if appURL != "" {
+		qrPath := "/rooms/" + url.PathEscape(roomCode) + "/qr"
+		layout["link"] = joinLink(roomCode, "")
+		layout["qr"] = map[string]string{"png": qrPath + ".png", "svg": qrPath + ".svg"}
+	}
+
+	data, _ := json.Marshal(layout)
+	return data
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/centrifugal/centrifuge"
+	"github.com/theWebPartyTime/server/internal/models"
+)
+
+const (
+	minReplaySpeed = 0.1
+	maxReplaySpeed = 16
+	// maxReplayWait caps the pause between two replayed events, however long
+	// the party originally paused.
+	maxReplayWait = 30 * time.Second
+)
+
+// replays holds the connections that have a replay running, since each gets
+// only one at a time.
+var replays sync.Map
+
+type replayRequest struct {
+	PartyID int      `json:"party_id"`
+	From    int      `json:"from"`
+	Speed   *float64 `json:"speed"`
+}
+
+// validReplaySpeed reports whether a replay can run at speed: either 0 or
+// between minReplaySpeed and maxReplaySpeed.
+func validReplaySpeed(speed float64) bool {
+	return speed == 0 || (speed >= minReplaySpeed && speed <= maxReplaySpeed)
+}
+
+// startReplay reserves the replay of a client, unless it already has one
+// running. replayParty releases it.
+func startReplay(client *centrifuge.Client) bool {
+	_, running := replays.LoadOrStore(client.ID(), struct{}{})
+	return !running
+}
+
+// replayParty sends the queries of a recorded party to a client again,
+// spaced as they originally were and sped up by speed. A speed of 0 sends
+// everything at once. It stops once the client disconnects.
+func replayParty(client *centrifuge.Client, events []models.PartyEvent, speed float64) {
+	defer replays.Delete(client.ID())
+
+	for i, event := range events {
+		if i > 0 && speed > 0 {
+			wait := time.Duration(float64(event.OccurredAt.Sub(events[i-1].OccurredAt)) / speed)
+			timer := time.NewTimer(min(wait, maxReplayWait))
+			select {
+			case <-timer.C:
+			case <-client.Context().Done():
+				timer.Stop()
+				return
+			}
+		}
+
+		data, _ := json.Marshal(response{
+			Type: "replay",
+			Message: map[string]any{
+				"seq":      event.Seq,
+				"step":     event.Data["step"],
+				"audience": event.Data["audience"],
+				"payload":  event.Data["payload"],
+			},
+		})
+
+		if err := client.Send(data); err != nil {
+			return
+		}
+	}
+
+	endMsg, _ := json.Marshal(response{
+		Type:    "replay_ended",
+		Message: map[string]any{},
+	})
+	client.Send(endMsg)
+}
@@ -3,18 +3,22 @@ package main
 import (
 	"encoding/json"
 	"fmt"
-	"log"
+	"maps"
 	"os"
+	"strings"
 	"time"
 
+	"github.com/theWebPartyTime/server/internal/achievements"
 	"github.com/theWebPartyTime/server/internal/conditions"
 	"github.com/theWebPartyTime/server/internal/input"
+	"github.com/theWebPartyTime/server/internal/models"
 	"github.com/theWebPartyTime/server/internal/partyflow"
 	"github.com/theWebPartyTime/server/internal/room"
 )
 
 func createRoom(owner string, hash string,
-	sendToPlayers func(string, []byte), sendToSpectators func(string, []byte)) (string, time.Time, error) {
+	sendToPlayers func(string, []byte), sendToSpectators func(string, []byte),
+	sendToUser func(string, []byte)) (string, time.Time, error) {
 
 	filePath := scriptsPath + hash
 	partyFlow, err := partyflow.New().FromFile(filePath, os.Stdout)
@@ -22,7 +26,7 @@ func createRoom(owner string, hash string,
 		return "", time.Time{}, fmt.Errorf("PartyFlow build failed:\n\t- %w", err)
 	}
 
-	room, err := rmManager().Allocate(owner, room.DefaultRoomConfig())
+	room_, err := rmManager().Allocate(owner, room.DefaultRoomConfig())
 	if err != nil {
 		return "", time.Time{}, fmt.Errorf("Room allocation failed:\n\t- %w", err)
 	}
@@ -30,130 +34,130 @@ func createRoom(owner string, hash string,
 	partyFlow.AddInputChecker("text", input.GetTextChecker())
 	partyFlow.AddCondition("timer", conditions.Timer, nil)
 	partyFlow.AddCondition("inputBased", conditions.Input,
-		map[string]any{"channel": room.GetInputReadyChannel()},
+		map[string]any{"channel": room_.GetInputReadyChannel()},
 	)
 
-	partyFlow.OnQuery(func(partyQuery *partyflow.PartyQuery) {
-		if partyQuery.Input != nil {
-			input := make(map[string]any)
-			for k, v := range partyQuery.Input {
-				input[k] = v
-			}
+	var queriedAt time.Time
+	tracker := achievements.NewTracker()
+	recorder := &partyRecorder{}
+	room_.SetOnEvent(func(event room.Event) {
+		recorder.do(func() {
+			logEvent(recorder.party, room_.GetCode(), event)
+		})
+	})
 
-			delete(input, "correct")
-			input["step"] = partyQuery.Step
+	// The PartyFlow runs on its own goroutine, so its callbacks take the
+	// room lock to read the room. The caller holds Mu.
+	_, roomMu, _ := rmManager().Room(room_.GetCode())
 
-			inputData, _ := json.Marshal(input)
-			sendToPlayers(room.GetCode(), inputData)
+	partyFlow.OnQuery(func(partyQuery *partyflow.PartyQuery) {
+		queriedAt = time.Now()
+		roomMu.RLock()
+		context := partyflow.TemplateContext{
+			Step:      partyQuery.Step,
+			Players:   room_.PlayerCount(),
+			Nicknames: maps.Clone(room_.GetNicknames()),
 		}
-
-		if partyQuery.Layout != nil {
-			layoutData, _ := json.Marshal(partyQuery.Layout)
-			sendToSpectators(room.GetCode(), layoutData)
+		language := room_.GetConfig().Language
+		players, playersOverridden := room_.Recipients(false)
+		spectators, spectatorsOverridden := room_.Recipients(true)
+		roomMu.RUnlock()
+
+		emitted := func(audience string, user string, data []byte) {
+			room_.Record(room.EventQuery, user, map[string]any{
+				"name": partyQuery.Name, "step": partyQuery.Step,
+				"audience": audience, "payload": json.RawMessage(data)})
 		}
-	})
 
-	partyFlow.OnGetWinners(func(partyQuery *partyflow.PartyQuery) []string {
-		winners := []string{}
-		if partyQuery.Input == nil {
-			return winners
+		if partyQuery.Input != nil {
+			sendSection(partyFlow, partyQuery.PlayerInput(), language, context,
+				players, playersOverridden, func(data []byte) {
+					sendToPlayers(room_.GetCode(), data)
+					emitted("players", "", data)
+				}, func(user string, data []byte) {
+					sendToUser(user, data)
+					emitted("players", user, data)
+				})
 		}
 
-		inputs := room.GetInputs()
-		var voteMap map[string]int = nil
-
-		queryType := partyQuery.Input["type"].(string)
-		if queryType == "vote all" {
-			voteMap = make(map[string]int)
+		if partyQuery.Layout != nil {
+			sendSection(partyFlow, partyQuery.SpectatorLayout(), language, context,
+				spectators, spectatorsOverridden, func(data []byte) {
+					sendToSpectators(room_.GetCode(), data)
+					emitted("spectators", "", data)
+				}, func(user string, data []byte) {
+					sendToUser(user, data)
+					emitted("spectators", user, data)
+				})
 		}
+	})
 
-		for userID, input := range inputs {
-			inputType := input.Type
-			if inputType != "input" {
-				log.Printf("Wrong input type sent in by <%s>", userID)
-				continue
-			}
-
-			step, ok := input.Content["step"].(float64)
-			if !ok {
-				log.Printf("Step not specified or specified incorrectly by <%s>", userID)
-				continue
-			}
-
-			message, ok := input.Content["message"].(string)
-			if !ok {
-				log.Printf("Content input not specified or specified incorrectly by <%s>", userID)
-				continue
-			}
-
-			contentType, ok := input.Content["type"].(string)
-			log.Printf("User passed type %v\n", contentType)
-			if !ok {
-				log.Printf("Content input type not specified or specified incorrectly by <%s>", userID)
-				continue
-			}
-
-			if contentType != queryType || step != float64(partyQuery.Step) {
-				log.Printf("User <%s> input relevance check failed: tried step %v, type %v (when need step %v, type %v)",
-					userID, step, contentType, partyQuery.Step, queryType)
-				continue
-			}
+	partyFlow.OnGetWinners(func(partyQuery *partyflow.PartyQuery) []string {
+		roomMu.RLock()
+		participants, inputs := room_.Participants(), maps.Clone(room_.GetInputs())
+		roomMu.RUnlock()
+
+		winners := room.Winners(partyFlow, partyQuery, inputs)
+		room_.Record(room.EventWinners, "", map[string]any{
+			"name": partyQuery.Name, "step": partyQuery.Step,
+			"winners": winners, "inputs": room.Messages(inputs)})
+		queried := queriedAt
+		recorder.do(func() {
+			recordAnswers(recorder.party, partyQuery, queried, participants, inputs, winners)
+		})
 
-			if queryType == "vote all" {
-				_, ok = voteMap[message]
-				if !ok {
-					voteMap[message] = 0
-				}
-				voteMap[message] += 1
-
-				votingWinner := ""
-				maxVotes := 0
-				for user, votes := range voteMap {
-					if votes > maxVotes {
-						votingWinner = user
-						maxVotes = votes
-					}
-				}
-
-				log.Printf("Voting concluded with: %v", voteMap)
-
-				if votingWinner != "" {
-					winners = append(winners, votingWinner)
-				}
-			} else {
-				correct := partyQuery.Input["correct"]
-				checker := partyFlow.GetInputChecker(queryType)
-
-				log.Printf("User sent <%v> to compare against <%v>\n", input.Content, correct)
-
-				if correct == "pick" {
-					limits := partyQuery.Input["limits"].([]any)
-					correct = checker.Pick(limits)
-					log.Printf("Picked correct option to be %v\n", correct)
-				}
-
-				if checker.IsCorrect(message, correct) {
-					log.Printf("User %v won\n", userID)
-					winners = append(winners, userID)
-				}
+		if inputType, _ := partyQuery.Input["type"].(string); partyQuery.Input != nil && !strings.HasPrefix(inputType, "vote") {
+			players := make([]string, 0, len(participants))
+			for _, participant := range participants {
+				players = append(players, participant.User)
 			}
+			tracker.Judged(players, winners)
 		}
-
 		return winners
 	})
 
 	partyFlow.OnGetInputs(func(partyQuery *partyflow.PartyQuery) map[string]string {
-		res := make(map[string]string)
+		roomMu.RLock()
+		defer roomMu.RUnlock()
 
-		for userID, input := range room.GetInputs() {
-			res[userID] = input.Content["message"].(string)
-		}
-
-		return res
+		return room.Messages(room_.GetInputs())
 	})
 
 	partyFlow.OnMove(func() {
-		room.ClearInputs()
+		roomMu.Lock()
+		defer roomMu.Unlock()
+
+		room_.ClearInputs()
+	})
+
+	// The room is started and ended under its write lock, which is held here.
+	room_.SetOnPartyStatus(func(status models.PartyStatus) {
+		host, _ := room_.GetAccount(owner)
+		participants, standings := room_.Participants(), maps.Clone(partyFlow.GetStandings())
+
+		if status == models.PartyActive {
+			tracker.Reset()
+			recorder.do(func() {
+				recorder.party = recordParty(recorder.party, status, room_.GetCode(), hash, host,
+					participants, standings)
+			})
+		} else {
+			progress := tracker.Snapshot()
+			recorder.do(func() {
+				ended := recorder.party
+				recorder.party = recordParty(recorder.party, status, room_.GetCode(), hash, host,
+					participants, standings)
+				awardAchievements(ended, status, progress, owner, sendToUser)
+			})
+			sendToSpectators(room_.GetCode(), lobbyLayout(room_.GetCode()))
+		}
+	})
+
+	partyFlow.OnPanic(func(any) {
+		roomMu.Lock()
+		defer roomMu.Unlock()
+
+		room_.Stop()
 	})
 
 	partyFlow.OnFinished(func() {
@@ -162,12 +166,37 @@ func createRoom(owner string, hash string,
 			Message: map[string]any{},
 		})
 
-		sendToPlayers(room.GetCode(), endMsg)
-		sendToSpectators(room.GetCode(), endMsg)
-		room.Stop()
+		sendToPlayers(room_.GetCode(), endMsg)
+		sendToSpectators(room_.GetCode(), endMsg)
+
+		roomMu.Lock()
+		defer roomMu.Unlock()
+
+		room_.Finish()
 	})
 
-	room.AttachPartyFlow(partyFlow)
+	room_.AttachPartyFlow(partyFlow)
 
-	return room.GetCode(), room.GetCreatedAt(), nil
+	return room_.GetCode(), room_.GetCreatedAt(), nil
+}
+
+// sendSection broadcasts a query section in the room language, unless some
+// recipient picked another language or the section has personal templates:
+// then everyone gets their own copy privately so that nobody receives it
+// twice.
+func sendSection(partyFlow *partyflow.PartyFlow, section map[string]any, roomLanguage string,
+	context partyflow.TemplateContext, recipients map[string]string, overridden bool,
+	broadcast func([]byte), sendToUser func(string, []byte)) {
+
+	if !overridden && !partyFlow.IsPersonal(section) {
+		data, _ := json.Marshal(partyFlow.Prepare(section, roomLanguage, context))
+		broadcast(data)
+		return
+	}
+
+	for user, language := range recipients {
+		context.Recipient = user
+		data, _ := json.Marshal(partyFlow.Prepare(section, language, context))
+		sendToUser(user, data)
+	}
 }
@@ -0,0 +1,68 @@
+package achievements
+
+import "github.com/theWebPartyTime/server/internal/models"
+
+// Definition is a server-side achievement rule, checked for every player
+// and for the host whenever a party ends.
+type Definition struct {
+	ID          string `json:"id"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+
+	earned func(Progress) bool
+}
+
+// Progress is what rules are checked against: how a player did in the party
+// that just ended and their lifetime stats, that party included.
+type Progress struct {
+	Party    PartyProgress
+	Finished bool
+	Lifetime models.ProfileStats
+}
+
+var Definitions = []Definition{
+	{
+		ID: "first_win", Title: "First win", Description: "Win a game.",
+		earned: func(p Progress) bool { return p.Lifetime.GamesWon >= 1 },
+	},
+	{
+		ID: "won_10_games", Title: "Champion", Description: "Win 10 games.",
+		earned: func(p Progress) bool { return p.Lifetime.GamesWon >= 10 },
+	},
+	{
+		ID: "hosted_20_parties", Title: "Party animal", Description: "Host 20 parties.",
+		earned: func(p Progress) bool { return p.Lifetime.GamesHosted >= 20 },
+	},
+	{
+		ID: "streak_5", Title: "On a roll", Description: "Answer 5 questions in a row correctly.",
+		earned: func(p Progress) bool { return p.Party.BestStreak >= 5 },
+	},
+	{
+		ID: "perfect_score", Title: "Perfect score", Description: "Answer every question of a game correctly.",
+		earned: func(p Progress) bool {
+			return p.Finished && p.Party.Judged > 0 && p.Party.Correct == p.Party.Judged
+		},
+	},
+}
+
+// Earned lists every achievement whose rule holds for progress.
+func Earned(progress Progress) []Definition {
+	earned := []Definition{}
+	for _, definition := range Definitions {
+		if definition.earned(progress) {
+			earned = append(earned, definition)
+		}
+	}
+
+	return earned
+}
+
+func Find(id string) (Definition, bool) {
+	for _, definition := range Definitions {
+		if definition.ID == id {
+			return definition, true
+		}
+	}
+
+	return Definition{}, false
+}
@@ -0,0 +1,44 @@
+package achievements
+
+import (
+	"testing"
+
+	"github.com/theWebPartyTime/server/internal/models"
+)
+
+func TestTrackerStreaks(t *testing.T) {
+	tracker := NewTracker()
+
+	for _, winners := range [][]string{{"a"}, {"a", "b"}, {"b"}, {"a", "b"}, {"a", "b"}, {"a", "b"}, {"a"}} {
+		tracker.Judged([]string{"a", "b"}, winners)
+	}
+
+	a, b := tracker.Progress("a"), tracker.Progress("b")
+	if a.BestStreak != 4 || a.Streak != 4 || a.Correct != 6 {
+		t.Fatalf("unexpected progress of a: %+v", a)
+	}
+	if b.BestStreak != 5 || b.Streak != 0 || b.Judged != 7 {
+		t.Fatalf("unexpected progress of b: %+v", b)
+	}
+}
+
+func TestEarned(t *testing.T) {
+	earned := Earned(Progress{
+		Party:    PartyProgress{Judged: 5, Correct: 5, BestStreak: 5},
+		Finished: true,
+		Lifetime: models.ProfileStats{GamesWon: 1},
+	})
+
+	ids := []string{}
+	for _, definition := range earned {
+		ids = append(ids, definition.ID)
+	}
+
+	if len(ids) != 3 || ids[0] != "first_win" || ids[1] != "streak_5" || ids[2] != "perfect_score" {
+		t.Fatalf("unexpected achievements %v", ids)
+	}
+
+	if len(Earned(Progress{Party: PartyProgress{Judged: 2, Correct: 2}})) != 0 {
+		t.Fatal("a perfect score requires a finished party")
+	}
+}
@@ -0,0 +1,79 @@
+package achievements
+
+import (
+	"slices"
+	"sync"
+)
+
+type PartyProgress struct {
+	Judged     int `json:"judged"`
+	Correct    int `json:"correct"`
+	Streak     int `json:"streak"`
+	BestStreak int `json:"best_streak"`
+}
+
+// Tracker follows the progress of every player of a party in memory, so
+// that judging a step costs next to nothing to the PartyFlow loop.
+type Tracker struct {
+	mu      sync.Mutex
+	players map[string]*PartyProgress
+}
+
+func NewTracker() *Tracker {
+	return &Tracker{players: make(map[string]*PartyProgress)}
+}
+
+func (tracker *Tracker) Reset() {
+	tracker.mu.Lock()
+	defer tracker.mu.Unlock()
+
+	clear(tracker.players)
+}
+
+// Judged records the outcome of a step for everyone who was playing it.
+func (tracker *Tracker) Judged(players []string, winners []string) {
+	tracker.mu.Lock()
+	defer tracker.mu.Unlock()
+
+	for _, player := range players {
+		progress, ok := tracker.players[player]
+		if !ok {
+			progress = &PartyProgress{}
+			tracker.players[player] = progress
+		}
+
+		progress.Judged += 1
+		if slices.Contains(winners, player) {
+			progress.Correct += 1
+			progress.Streak += 1
+			progress.BestStreak = max(progress.BestStreak, progress.Streak)
+		} else {
+			progress.Streak = 0
+		}
+	}
+}
+
+func (tracker *Tracker) Progress(player string) PartyProgress {
+	tracker.mu.Lock()
+	defer tracker.mu.Unlock()
+
+	progress, ok := tracker.players[player]
+	if !ok {
+		return PartyProgress{}
+	}
+
+	return *progress
+}
+
+// Snapshot copies the progress of every player seen so far.
+func (tracker *Tracker) Snapshot() map[string]PartyProgress {
+	tracker.mu.Lock()
+	defer tracker.mu.Unlock()
+
+	progress := make(map[string]PartyProgress, len(tracker.players))
+	for player, playerProgress := range tracker.players {
+		progress[player] = *playerProgress
+	}
+
+	return progress
+}
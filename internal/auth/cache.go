@@ -0,0 +1,63 @@
+package auth
+
+import (
+	"sync"
+	"time"
+)
+
+// maxCacheEntries bounds a cache: past it, expired entries are swept and if
+// that is not enough the cache starts over.
+const maxCacheEntries = 10000
+
+// cache remembers what the database said about sessions and accounts for a
+// little while, so that checking every request does not cost a query each.
+type cache[K comparable, V any] struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	entries map[K]cacheEntry[V]
+}
+
+type cacheEntry[V any] struct {
+	value     V
+	expiresAt time.Time
+}
+
+func newCache[K comparable, V any](ttl time.Duration) *cache[K, V] {
+	return &cache[K, V]{ttl: ttl, entries: make(map[K]cacheEntry[V])}
+}
+
+// get returns the cached value of key, loading it if it is missing or
+// stale. Failed loads are not cached.
+func (c *cache[K, V]) get(key K, load func() (V, error)) (V, error) {
+	now := time.Now()
+
+	c.mu.Lock()
+	entry, ok := c.entries[key]
+	c.mu.Unlock()
+
+	if ok && now.Before(entry.expiresAt) {
+		return entry.value, nil
+	}
+
+	value, err := load()
+	if err != nil {
+		return value, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(c.entries) >= maxCacheEntries {
+		for key, entry := range c.entries {
+			if now.After(entry.expiresAt) {
+				delete(c.entries, key)
+			}
+		}
+		if len(c.entries) >= maxCacheEntries {
+			clear(c.entries)
+		}
+	}
+
+	c.entries[key] = cacheEntry[V]{value: value, expiresAt: now.Add(c.ttl)}
+	return value, nil
+}
@@ -0,0 +1,41 @@
+package auth
+
+import (
+	"errors"
+	"time"
+
+	go_jwt "github.com/golang-jwt/jwt/v5"
+)
+
+// GuestTTL is how long a guest token lasts. Guests are meant to come back
+// without an account, so it is long.
+const GuestTTL = 90 * 24 * time.Hour
+
+var ErrInvalidGuestToken = errors.New("invalid guest token")
+
+// GuestToken signs a token standing for the guest guestId.
+func (k *KeySet) GuestToken(guestId string) (string, error) {
+	now := time.Now()
+	return k.Sign(go_jwt.MapClaims{
+		"gid": guestId,
+		"iat": now.Unix(),
+		"exp": now.Add(GuestTTL).Unix(),
+		"typ": "guest",
+	})
+}
+
+// ParseGuestToken returns the guest a token produced by GuestToken stands for.
+func (k *KeySet) ParseGuestToken(tokenString string) (string, error) {
+	token, err := k.Parse(tokenString)
+	if err != nil || !token.Valid {
+		return "", ErrInvalidGuestToken
+	}
+
+	claims, ok := token.Claims.(go_jwt.MapClaims)
+	guestId, isString := claims["gid"].(string)
+	if !ok || !isString || guestId == "" || claims["typ"] != "guest" {
+		return "", ErrInvalidGuestToken
+	}
+
+	return guestId, nil
+}
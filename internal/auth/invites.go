@@ -0,0 +1,58 @@
+package auth
+
+import (
+	"errors"
+	"time"
+
+	go_jwt "github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	InvitePlayer    = "player"
+	InviteSpectator = "spectator"
+)
+
+var ErrInvalidInvite = errors.New("invalid invite")
+
+// Invite lets its holder into a room as a player or a spectator. It names
+// the room by code and creation time, so it dies along with the room even
+// if the code is handed out again.
+type Invite struct {
+	Room      string
+	CreatedAt time.Time
+	Role      string
+}
+
+// InviteToken signs an invite that expires after ttl.
+func (k *KeySet) InviteToken(invite Invite, ttl time.Duration) (string, error) {
+	now := time.Now()
+	return k.Sign(go_jwt.MapClaims{
+		"room": invite.Room,
+		"rca":  invite.CreatedAt.Unix(),
+		"role": invite.Role,
+		"iat":  now.Unix(),
+		"exp":  now.Add(ttl).Unix(),
+		"typ":  "invite",
+	})
+}
+
+func (k *KeySet) ParseInviteToken(tokenString string) (*Invite, error) {
+	token, err := k.Parse(tokenString)
+	if err != nil || !token.Valid {
+		return nil, ErrInvalidInvite
+	}
+
+	claims, ok := token.Claims.(go_jwt.MapClaims)
+	if !ok || claims["typ"] != "invite" {
+		return nil, ErrInvalidInvite
+	}
+
+	room, _ := claims["room"].(string)
+	createdAt, _ := claims["rca"].(float64)
+	role, _ := claims["role"].(string)
+	if room == "" || (role != InvitePlayer && role != InviteSpectator) {
+		return nil, ErrInvalidInvite
+	}
+
+	return &Invite{Room: room, CreatedAt: time.Unix(int64(createdAt), 0), Role: role}, nil
+}
@@ -0,0 +1,232 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	go_jwt "github.com/golang-jwt/jwt/v5"
+)
+
+var ErrUnknownKey = errors.New("unknown signing key")
+
+// SigningKey is an Ed25519 or RSA key. Keys that are only kept to verify
+// tokens signed before a rotation have no private half.
+type SigningKey struct {
+	ID      string
+	Method  go_jwt.SigningMethod
+	Private crypto.Signer
+	Public  crypto.PublicKey
+	// secret is set instead of both halves for a SecretKey.
+	secret []byte
+}
+
+// SecretKeyID names the key made by SecretKey. Tokens without a kid header
+// are verified with it, since they were signed before keys had ids.
+const SecretKeyID = "secret"
+
+// SecretKey turns the shared HS256 secret tokens used to be signed with into
+// a key. It is never published in the JWKS.
+func SecretKey(secret []byte) *SigningKey {
+	return &SigningKey{ID: SecretKeyID, Method: go_jwt.SigningMethodHS256, secret: secret}
+}
+
+// KeySet signs tokens with its active key and verifies them with any key it
+// holds. Retiring a key means removing it from the set.
+type KeySet struct {
+	active *SigningKey
+	keys   map[string]*SigningKey
+}
+
+func NewKeySet(active *SigningKey, verification ...*SigningKey) *KeySet {
+	keys := map[string]*SigningKey{active.ID: active}
+	for _, key := range verification {
+		keys[key.ID] = key
+	}
+
+	return &KeySet{active: active, keys: keys}
+}
+
+// GenerateKey creates a fresh Ed25519 key.
+func GenerateKey(id string) (*SigningKey, error) {
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	return &SigningKey{ID: id, Method: go_jwt.SigningMethodEdDSA, Private: private, Public: public}, nil
+}
+
+// LoadKeySet reads every <kid>.pem file of dir, holding a PKCS#8 private key
+// or a PKIX public key. The key named activeID signs new tokens; extra keys
+// only verify them.
+func LoadKeySet(dir string, activeID string, extra ...*SigningKey) (*KeySet, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.pem"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(paths)
+
+	var active *SigningKey
+	verification := append([]*SigningKey{}, extra...)
+
+	for _, path := range paths {
+		id := strings.TrimSuffix(filepath.Base(path), ".pem")
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		key, err := parseKey(id, data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+
+		if id == activeID {
+			active = key
+		} else {
+			verification = append(verification, key)
+		}
+	}
+
+	if active == nil || active.Private == nil {
+		return nil, fmt.Errorf("no private key %q in %s", activeID, dir)
+	}
+
+	return NewKeySet(active, verification...), nil
+}
+
+// Sign issues a token with the active key, naming it in the kid header.
+func (k *KeySet) Sign(claims go_jwt.Claims) (string, error) {
+	token := go_jwt.NewWithClaims(k.active.Method, claims)
+	token.Header["kid"] = k.active.ID
+	if k.active.secret != nil {
+		return token.SignedString(k.active.secret)
+	}
+	return token.SignedString(k.active.Private)
+}
+
+// Parse verifies a token against the key its kid header names.
+func (k *KeySet) Parse(tokenString string) (*go_jwt.Token, error) {
+	return go_jwt.Parse(tokenString, func(t *go_jwt.Token) (interface{}, error) {
+		kid, ok := t.Header["kid"].(string)
+		if !ok {
+			kid = SecretKeyID
+		}
+		key, ok := k.keys[kid]
+		if !ok {
+			return nil, ErrUnknownKey
+		}
+		if t.Method.Alg() != key.Method.Alg() {
+			return nil, go_jwt.ErrSignatureInvalid
+		}
+		if key.secret != nil {
+			return key.secret, nil
+		}
+		return key.Public, nil
+	}, go_jwt.WithValidMethods([]string{go_jwt.SigningMethodEdDSA.Alg(), go_jwt.SigningMethodRS256.Alg(),
+		go_jwt.SigningMethodHS256.Alg()}))
+}
+
+type JWK struct {
+	Kid string `json:"kid"`
+	Kty string `json:"kty"`
+	Alg string `json:"alg"`
+	Use string `json:"use"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+}
+
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS publishes the public halves of all keys so other services can verify
+// our tokens.
+func (k *KeySet) JWKS() JWKS {
+	ids := make([]string, 0, len(k.keys))
+	for id := range k.keys {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	jwks := JWKS{Keys: []JWK{}}
+	for _, id := range ids {
+		key := k.keys[id]
+		if key.secret != nil {
+			continue
+		}
+
+		jwk := JWK{Kid: key.ID, Alg: key.Method.Alg(), Use: "sig"}
+
+		switch public := key.Public.(type) {
+		case ed25519.PublicKey:
+			jwk.Kty = "OKP"
+			jwk.Crv = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(public)
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(public.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes())
+		}
+
+		jwks.Keys = append(jwks.Keys, jwk)
+	}
+
+	return jwks
+}
+
+func parseKey(id string, data []byte) (*SigningKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM block")
+	}
+
+	key := &SigningKey{ID: id}
+	switch block.Type {
+	case "PRIVATE KEY":
+		private, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		signer, ok := private.(crypto.Signer)
+		if !ok {
+			return nil, errors.New("unsupported private key")
+		}
+		key.Private = signer
+		key.Public = signer.Public()
+	case "PUBLIC KEY":
+		public, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		key.Public = public
+	default:
+		return nil, fmt.Errorf("unsupported PEM block %s", block.Type)
+	}
+
+	switch key.Public.(type) {
+	case ed25519.PublicKey:
+		key.Method = go_jwt.SigningMethodEdDSA
+	case *rsa.PublicKey:
+		key.Method = go_jwt.SigningMethodRS256
+	default:
+		return nil, errors.New("only Ed25519 and RSA keys are supported")
+	}
+
+	return key, nil
+}
@@ -0,0 +1,169 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	go_jwt "github.com/golang-jwt/jwt/v5"
+)
+
+func writeKey(t *testing.T, dir string, id string, key any) {
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+	if err := os.WriteFile(filepath.Join(dir, id+".pem"), data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestKeyRotation(t *testing.T) {
+	dir := t.TempDir()
+
+	_, ed25519Key, _ := ed25519.GenerateKey(rand.Reader)
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeKey(t, dir, "old", rsaKey)
+	writeKey(t, dir, "new", ed25519Key)
+
+	before, err := LoadKeySet(dir, "old")
+	if err != nil {
+		t.Fatal(err)
+	}
+	oldToken, err := before.Sign(go_jwt.MapClaims{"id": 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	after, err := LoadKeySet(dir, "new")
+	if err != nil {
+		t.Fatal(err)
+	}
+	newToken, err := after.Sign(go_jwt.MapClaims{"id": 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, token := range map[string]string{"old": oldToken, "new": newToken} {
+		if _, err := after.Parse(token); err != nil {
+			t.Errorf("token signed with the %s key was rejected: %v", name, err)
+		}
+	}
+
+	parsed, _ := after.Parse(newToken)
+	if parsed.Header["kid"] != "new" || parsed.Method.Alg() != "EdDSA" {
+		t.Errorf("new token header = %v", parsed.Header)
+	}
+
+	jwks := after.JWKS()
+	if len(jwks.Keys) != 2 || jwks.Keys[0].Kid != "new" || jwks.Keys[0].Kty != "OKP" || jwks.Keys[1].Kty != "RSA" {
+		t.Errorf("jwks = %+v", jwks)
+	}
+
+	os.Remove(filepath.Join(dir, "old.pem"))
+	retired, err := LoadKeySet(dir, "new")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := retired.Parse(oldToken); err == nil {
+		t.Error("token signed with a retired key was accepted")
+	}
+}
+
+func TestSecretKey(t *testing.T) {
+	dir := t.TempDir()
+	_, ed25519Key, _ := ed25519.GenerateKey(rand.Reader)
+	writeKey(t, dir, "new", ed25519Key)
+
+	secret := []byte("former secret")
+	legacy := go_jwt.NewWithClaims(go_jwt.SigningMethodHS256, go_jwt.MapClaims{"id": 1})
+	legacyToken, _ := legacy.SignedString(secret)
+
+	keys, err := LoadKeySet(dir, "new", SecretKey(secret))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := keys.Parse(legacyToken); err != nil {
+		t.Errorf("token signed with the former secret was rejected: %v", err)
+	}
+	if jwks := keys.JWKS(); len(jwks.Keys) != 1 || jwks.Keys[0].Kid != "new" {
+		t.Errorf("jwks = %+v", jwks)
+	}
+
+	forged := go_jwt.NewWithClaims(go_jwt.SigningMethodHS256, go_jwt.MapClaims{"id": 1})
+	forged.Header["kid"] = "new"
+	forgedToken, _ := forged.SignedString(secret)
+	if _, err := keys.Parse(forgedToken); err == nil {
+		t.Error("HS256 token naming an Ed25519 key was accepted")
+	}
+
+	signing := NewKeySet(SecretKey(secret))
+	token, err := signing.Sign(go_jwt.MapClaims{"id": 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := keys.Parse(token); err != nil {
+		t.Errorf("token signed with JWT_SECRET was rejected: %v", err)
+	}
+}
+
+func TestGuestToken(t *testing.T) {
+	key, err := GenerateKey("guests")
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys := NewKeySet(key)
+
+	token, err := keys.GuestToken("guest-id")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if guestId, err := keys.ParseGuestToken(token); err != nil || guestId != "guest-id" {
+		t.Errorf("ParseGuestToken = %q, %v", guestId, err)
+	}
+
+	access, _ := keys.Sign(go_jwt.MapClaims{"id": 1, "typ": "access"})
+	if _, err := keys.ParseGuestToken(access); err == nil {
+		t.Error("access token was accepted as a guest token")
+	}
+}
+
+func TestInviteToken(t *testing.T) {
+	key, err := GenerateKey("invites")
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys := NewKeySet(key)
+
+	createdAt := time.Unix(1700000000, 0)
+	token, err := keys.InviteToken(Invite{Room: "ABCDEFGHI", CreatedAt: createdAt, Role: InviteSpectator}, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	invite, err := keys.ParseInviteToken(token)
+	if err != nil || invite.Room != "ABCDEFGHI" || !invite.CreatedAt.Equal(createdAt) || invite.Role != InviteSpectator {
+		t.Errorf("ParseInviteToken = %+v, %v", invite, err)
+	}
+
+	expired, _ := keys.InviteToken(Invite{Room: "ABCDEFGHI", CreatedAt: createdAt, Role: InvitePlayer}, -time.Minute)
+	if _, err := keys.ParseInviteToken(expired); err == nil {
+		t.Error("expired invite was accepted")
+	}
+
+	guest, _ := keys.GuestToken("guest-id")
+	if _, err := keys.ParseInviteToken(guest); err == nil {
+		t.Error("guest token was accepted as an invite")
+	}
+}
@@ -1,29 +1,99 @@
 package auth
 
 import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net"
 	"net/http"
+	"slices"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/theWebPartyTime/server/internal/models"
+	"github.com/theWebPartyTime/server/internal/service"
 
 	"github.com/centrifugal/centrifuge"
 	"github.com/gin-gonic/gin"
 	go_jwt "github.com/golang-jwt/jwt/v5"
 )
 
+// TokenVerifier checks personal access tokens.
+type TokenVerifier interface {
+	VerifyToken(ctx context.Context, token string) (*models.User, []string, error)
+}
+
+// SessionVerifier tells whether a session was revoked.
+type SessionVerifier interface {
+	SessionActive(ctx context.Context, id string) (bool, error)
+}
+
+// AccountLoader loads accounts, whose role and ban are taken from the
+// database rather than from access tokens.
+type AccountLoader interface {
+	GetUserByID(ctx context.Context, id int) (*models.User, error)
+}
+
+var (
+	// ErrSessionRevoked is returned for access tokens of a revoked session.
+	ErrSessionRevoked = errors.New("session revoked")
+	// ErrAccountBanned is returned for access tokens of a banned account.
+	ErrAccountBanned = errors.New("account banned")
+)
+
+const (
+	// sessionCacheTTL is how long a revoked session, or a banned or
+	// demoted account, may keep being let in.
+	sessionCacheTTL = 15 * time.Second
+	// connectionCheckInterval is how often the session and account of a
+	// websocket connection are checked again.
+	connectionCheckInterval = 30 * time.Second
+)
+
 type JWTMiddleware struct {
-	SecretKey []byte
+	Keys     *KeySet
+	Tokens   TokenVerifier
+	Sessions SessionVerifier
+	Accounts AccountLoader
+	// networkKey keys the hashes connections carry instead of their
+	// address, since connection info is visible to other clients.
+	networkKey []byte
+	sessions   *cache[string, bool]
+	accounts   *cache[int, accountState]
 }
 
-func NewJWTMiddleware(secretKey []byte) *JWTMiddleware {
-	return &JWTMiddleware{SecretKey: secretKey}
+type accountState struct {
+	role   models.Role
+	banned bool
 }
 
-func (m *JWTMiddleware) GinAuthMiddleware() gin.HandlerFunc {
+func NewJWTMiddleware(keys *KeySet, tokens TokenVerifier, sessions SessionVerifier, accounts AccountLoader) *JWTMiddleware {
+	networkKey := make([]byte, 32)
+	rand.Read(networkKey)
+
+	return &JWTMiddleware{Keys: keys, Tokens: tokens, Sessions: sessions, Accounts: accounts,
+		networkKey: networkKey,
+		sessions:   newCache[string, bool](sessionCacheTTL),
+		accounts:   newCache[int, accountState](sessionCacheTTL)}
+}
+
+// GinAuthMiddleware accepts access tokens, and personal access tokens holding
+// any of scopes. Without scopes personal access tokens are turned away.
+func (m *JWTMiddleware) GinAuthMiddleware(scopes ...string) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		user, err := m.parseToken(c.GetHeader("Authorization"))
+		authHeader := c.GetHeader("Authorization")
+		if token, ok := strings.CutPrefix(authHeader, "Bearer "); ok && strings.HasPrefix(token, service.AccessTokenPrefix) {
+			m.personalAccessToken(c, token, scopes)
+			return
+		}
+
+		user, session, err := m.parseToken(c.Request.Context(), authHeader)
 		if err != nil {
 			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
 				"error": "unauthorized",
@@ -32,35 +102,74 @@ func (m *JWTMiddleware) GinAuthMiddleware() gin.HandlerFunc {
 		}
 
 		c.Set("user", user)
+		c.Set("session", session)
+		c.Next()
+	}
+}
+
+func (m *JWTMiddleware) personalAccessToken(c *gin.Context, token string, scopes []string) {
+	user, granted, err := m.Tokens.VerifyToken(c.Request.Context(), token)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+			"error": "unauthorized",
+		})
+		return
+	}
+
+	allowed := slices.ContainsFunc(granted, func(scope string) bool {
+		return slices.Contains(scopes, scope)
+	})
+	if !allowed {
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+			"error": "access token lacks the scope for this",
+		})
+		return
+	}
+
+	c.Set("user", &models.User{ID: user.ID, Email: user.Email, Role: models.RoleUser})
+	c.Set("scopes", granted)
+	c.Next()
+}
+
+// RequireScope narrows down what personal access tokens let into a route to
+// those holding scope. Access tokens are let through.
+func RequireScope(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		granted, ok := c.Get("scopes")
+		if ok && !slices.Contains(granted.([]string), scope) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+				"error": "access token lacks the scope for this",
+			})
+			return
+		}
+
 		c.Next()
 	}
 }
 
-func (m *JWTMiddleware) parseToken(authHeader string) (*models.User, error) {
+// parseToken returns the user an access token was issued to along with the
+// session it belongs to, as long as the session was not revoked and the
+// account is not banned. The role is the current one of the account.
+func (m *JWTMiddleware) parseToken(ctx context.Context, authHeader string) (*models.User, string, error) {
 	if authHeader == "" {
-		return nil, http.ErrNoCookie
+		return nil, "", http.ErrNoCookie
 	}
 
 	parts := strings.Split(authHeader, " ")
 	if len(parts) != 2 || parts[0] != "Bearer" {
-		return nil, http.ErrNoCookie
+		return nil, "", http.ErrNoCookie
 	}
 
 	tokenStr := parts[1]
 
-	token, err := go_jwt.Parse(tokenStr, func(t *go_jwt.Token) (interface{}, error) {
-		if t.Method != go_jwt.SigningMethodHS256 {
-			return nil, go_jwt.ErrSignatureInvalid
-		}
-		return m.SecretKey, nil
-	})
+	token, err := m.Keys.Parse(tokenStr)
 	if err != nil || !token.Valid {
-		return nil, err
+		return nil, "", err
 	}
 
 	claims, ok := token.Claims.(go_jwt.MapClaims)
 	if !ok || claims["typ"] != "access" {
-		return nil, http.ErrNoCookie
+		return nil, "", http.ErrNoCookie
 	}
 
 	user := &models.User{
@@ -68,13 +177,75 @@ func (m *JWTMiddleware) parseToken(authHeader string) (*models.User, error) {
 		Email: claims["email"].(string),
 	}
 
-	return user, nil
+	session, _ := claims["sid"].(string)
+	if err := m.verify(ctx, user, session); err != nil {
+		return nil, "", err
+	}
+
+	return user, session, nil
+}
+
+// verify checks that session is still active and that the account of user
+// is not banned, and sets the role user currently holds.
+func (m *JWTMiddleware) verify(ctx context.Context, user *models.User, session string) error {
+	if !m.sessionActive(ctx, session) {
+		return ErrSessionRevoked
+	}
+
+	account, err := m.accounts.get(user.ID, func() (accountState, error) {
+		loaded, err := m.Accounts.GetUserByID(ctx, user.ID)
+		if err != nil {
+			return accountState{}, err
+		}
+		return accountState{role: loaded.Role, banned: loaded.BannedAt != nil}, nil
+	})
+	if err != nil {
+		return err
+	}
+	if account.banned {
+		return ErrAccountBanned
+	}
+
+	user.Role = account.role
+	if user.Role == "" {
+		user.Role = models.RoleUser
+	}
+
+	return nil
+}
+
+func (m *JWTMiddleware) sessionActive(ctx context.Context, session string) bool {
+	if session == "" {
+		return false
+	}
+
+	active, err := m.sessions.get(session, func() (bool, error) {
+		return m.Sessions.SessionActive(ctx, session)
+	})
+	return err == nil && active
+}
+
+// RequireRole lets through only users holding one of roles. It has to run
+// after GinAuthMiddleware, which loads the current role of the account.
+func RequireRole(roles ...models.Role) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		u, ok := c.Get("user")
+		user, isUser := u.(*models.User)
+		if !ok || !isUser || !slices.Contains(roles, user.Role) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+				"error": "forbidden",
+			})
+			return
+		}
+
+		c.Next()
+	}
 }
 
 func (m *JWTMiddleware) WSAuthMiddleware(h http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 
-		user, err := m.parseToken(r.Header.Get("Authorization"))
+		user, _, err := m.parseToken(r.Context(), r.Header.Get("Authorization"))
 		if err != nil {
 			http.Error(w, "unauthorized", http.StatusUnauthorized)
 			return
@@ -92,16 +263,104 @@ func (m *JWTMiddleware) WSAuthMiddleware(h http.Handler) http.Handler {
 	})
 }
 
-func CentrifugeAuthMiddleware(h http.Handler) http.Handler {
+type connectionInfo struct {
+	AccountID int    `json:"account_id,omitempty"`
+	GuestID   string `json:"guest_id,omitempty"`
+	Network   string `json:"network,omitempty"`
+}
+
+// CentrifugeAuthMiddleware gives every connection a fresh identity. A valid
+// access token, passed as the token query parameter since browsers can not
+// set headers on websockets, additionally links the connection to an account.
+// Without one, a guest token passed as the guest query parameter links it to
+// a guest instead. Connections linked to an account expire every so often to
+// have their session checked again by RefreshConnection.
+func (m *JWTMiddleware) CentrifugeAuthMiddleware(h http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		context := r.Context()
+		ctx := r.Context()
 		credentials := &centrifuge.Credentials{UserID: uuid.NewString()}
-		newContext := centrifuge.SetCredentials(context, credentials)
-		r = r.WithContext(newContext)
+
+		authHeader := r.Header.Get("Authorization")
+		if token := r.URL.Query().Get("token"); token != "" {
+			authHeader = "Bearer " + token
+		}
+
+		info := connectionInfo{Network: m.network(r.RemoteAddr)}
+		if user, session, err := m.parseToken(ctx, authHeader); err == nil && user != nil {
+			info.AccountID = user.ID
+			credentials.ExpireAt = time.Now().Add(connectionCheckInterval).Unix()
+			ctx = context.WithValue(ctx, connectionKey{}, connectionAuth{account: user.ID, session: session})
+		} else if guestId, err := m.Keys.ParseGuestToken(r.URL.Query().Get("guest")); err == nil {
+			info.GuestID = guestId
+		}
+		credentials.Info, _ = json.Marshal(info)
+
+		r = r.WithContext(centrifuge.SetCredentials(ctx, credentials))
 		h.ServeHTTP(w, r)
 	})
 }
 
+// connectionKey holds the connectionAuth of a connection in its context.
+type connectionKey struct{}
+
+// connectionAuth is what a connection was authenticated with.
+type connectionAuth struct {
+	account int
+	session string
+}
+
+// RefreshConnection disconnects a connection once the session it was
+// authenticated with gets revoked or its account gets banned.
+func (m *JWTMiddleware) RefreshConnection(client *centrifuge.Client) centrifuge.RefreshHandler {
+	return func(e centrifuge.RefreshEvent, cb centrifuge.RefreshCallback) {
+		connection, _ := client.Context().Value(connectionKey{}).(connectionAuth)
+		if err := m.verify(client.Context(), &models.User{ID: connection.account}, connection.session); err != nil {
+			cb(centrifuge.RefreshReply{Expired: true}, nil)
+			return
+		}
+
+		cb(centrifuge.RefreshReply{ExpireAt: time.Now().Add(connectionCheckInterval).Unix()}, nil)
+	}
+}
+
+// AccountFromInfo returns the account a connection was authenticated with.
+func AccountFromInfo(info []byte) (int, bool) {
+	var connection connectionInfo
+	if err := json.Unmarshal(info, &connection); err != nil || connection.AccountID == 0 {
+		return 0, false
+	}
+
+	return connection.AccountID, true
+}
+
+// NetworkFromInfo returns a stand-in for the address a connection came from.
+func NetworkFromInfo(info []byte) string {
+	var connection connectionInfo
+	json.Unmarshal(info, &connection)
+	return connection.Network
+}
+
+func (m *JWTMiddleware) network(remoteAddr string) string {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		host = remoteAddr
+	}
+
+	mac := hmac.New(sha256.New, m.networkKey)
+	mac.Write([]byte(host))
+	return hex.EncodeToString(mac.Sum(nil)[:12])
+}
+
+// GuestFromInfo returns the guest a connection was linked to.
+func GuestFromInfo(info []byte) (string, bool) {
+	var connection connectionInfo
+	if err := json.Unmarshal(info, &connection); err != nil || connection.GuestID == "" {
+		return "", false
+	}
+
+	return connection.GuestID, true
+}
+
 // func (m *JWTMiddleware) WSIdentityMiddleware(h http.Handler) http.Handler {
 // 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 
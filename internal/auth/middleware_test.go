@@ -0,0 +1,87 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/theWebPartyTime/server/internal/models"
+
+	go_jwt "github.com/golang-jwt/jwt/v5"
+)
+
+type fakeSessions map[string]bool
+
+func (sessions fakeSessions) SessionActive(ctx context.Context, id string) (bool, error) {
+	return sessions[id], nil
+}
+
+type fakeAccounts map[int]*models.User
+
+func (accounts fakeAccounts) GetUserByID(ctx context.Context, id int) (*models.User, error) {
+	user, ok := accounts[id]
+	if !ok {
+		return nil, errors.New("not found")
+	}
+	return user, nil
+}
+
+func TestRevokedSession(t *testing.T) {
+	key, err := GenerateKey("access")
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys := NewKeySet(key)
+	m := NewJWTMiddleware(keys, nil, fakeSessions{"active": true, "revoked": false},
+		fakeAccounts{1: {ID: 1, Role: models.RoleUser}})
+
+	bearer := func(session string) string {
+		claims := go_jwt.MapClaims{"id": 1, "email": "a@b.c", "typ": "access",
+			"exp": time.Now().Add(time.Minute).Unix()}
+		if session != "" {
+			claims["sid"] = session
+		}
+		token, _ := keys.Sign(claims)
+		return "Bearer " + token
+	}
+
+	if user, session, err := m.parseToken(context.Background(), bearer("active")); err != nil || user.ID != 1 || session != "active" {
+		t.Errorf("parseToken(active) = %v, %q, %v", user, session, err)
+	}
+
+	for _, session := range []string{"revoked", "unknown", ""} {
+		if _, _, err := m.parseToken(context.Background(), bearer(session)); !errors.Is(err, ErrSessionRevoked) {
+			t.Errorf("parseToken(%q) = %v, want ErrSessionRevoked", session, err)
+		}
+	}
+}
+
+func TestAccountFromDatabase(t *testing.T) {
+	key, err := GenerateKey("access")
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys := NewKeySet(key)
+	bannedAt := time.Now()
+	m := NewJWTMiddleware(keys, nil, fakeSessions{"session": true}, fakeAccounts{
+		1: {ID: 1, Role: models.RoleUser},
+		2: {ID: 2, Role: models.RoleAdmin, BannedAt: &bannedAt},
+	})
+
+	bearer := func(id int) string {
+		token, _ := keys.Sign(go_jwt.MapClaims{"id": id, "email": "a@b.c", "typ": "access",
+			"sid": "session", "role": string(models.RoleAdmin), "exp": time.Now().Add(time.Minute).Unix()})
+		return "Bearer " + token
+	}
+
+	if user, _, err := m.parseToken(context.Background(), bearer(1)); err != nil || user.Role != models.RoleUser {
+		t.Errorf("parseToken(1) = %v, %v, want the role of the database", user, err)
+	}
+	if _, _, err := m.parseToken(context.Background(), bearer(2)); !errors.Is(err, ErrAccountBanned) {
+		t.Errorf("parseToken(2) = %v, want ErrAccountBanned", err)
+	}
+	if _, _, err := m.parseToken(context.Background(), bearer(3)); err == nil {
+		t.Error("token of an unknown account was accepted")
+	}
+}
@@ -2,6 +2,7 @@ package config
 
 import (
 	"os"
+	"strings"
 )
 
 type DBConfig struct {
@@ -12,9 +13,41 @@ type DBConfig struct {
 	Name     string
 }
 
+// MailConfig selects how mail is delivered: through SMTP when Host is set,
+// otherwise into Dir (or the log, when Dir is empty too).
+type MailConfig struct {
+	Host     string
+	Port     string
+	Username string
+	Password string
+	From     string
+	Dir      string
+}
+
+type OIDCProviderConfig struct {
+	Name         string
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+}
+
 type Config struct {
-	DB        DBConfig
+	DB   DBConfig
+	Mail MailConfig
+	OIDC []OIDCProviderConfig
+	// JWTKeysDir holds the <kid>.pem keys access tokens are verified with;
+	// JWTActiveKey names the one new tokens are signed with.
+	JWTKeysDir   string
+	JWTActiveKey string
+	// JWTSecret is the HS256 secret tokens were signed with before keys
+	// could be rotated. Without JWTKeysDir it still signs new tokens,
+	// otherwise it only verifies the old ones.
 	JWTSecret string
+	AppURL    string
+	// RedisURL switches rate limiting from process memory to Redis, so that
+	// limits hold across instances.
+	RedisURL string
 }
 
 func LoadConfig() Config {
@@ -27,7 +60,44 @@ func LoadConfig() Config {
 			Password: os.Getenv("POSTGRES_PASSWORD"),
 			Name:     os.Getenv("POSTGRES_DB"),
 		},
-		JWTSecret: os.Getenv("JWT_SECRET"),
+		Mail: MailConfig{
+			Host:     os.Getenv("SMTP_HOST"),
+			Port:     os.Getenv("SMTP_PORT"),
+			Username: os.Getenv("SMTP_USERNAME"),
+			Password: os.Getenv("SMTP_PASSWORD"),
+			From:     os.Getenv("MAIL_FROM"),
+			Dir:      os.Getenv("MAIL_DIR"),
+		},
+		OIDC:         loadOIDCProviders(),
+		JWTKeysDir:   os.Getenv("JWT_KEYS_DIR"),
+		JWTActiveKey: os.Getenv("JWT_ACTIVE_KEY"),
+		JWTSecret:    os.Getenv("JWT_SECRET"),
+		AppURL:       os.Getenv("APP_URL"),
+		RedisURL:     os.Getenv("REDIS_URL"),
+	}
+
+}
+
+// loadOIDCProviders reads the providers listed in OIDC_PROVIDERS, each
+// configured by its own OIDC_<NAME>_* variables.
+func loadOIDCProviders() []OIDCProviderConfig {
+	var providers []OIDCProviderConfig
+
+	for _, name := range strings.Split(os.Getenv("OIDC_PROVIDERS"), ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+
+		prefix := "OIDC_" + strings.ToUpper(name) + "_"
+		providers = append(providers, OIDCProviderConfig{
+			Name:         name,
+			Issuer:       os.Getenv(prefix + "ISSUER"),
+			ClientID:     os.Getenv(prefix + "CLIENT_ID"),
+			ClientSecret: os.Getenv(prefix + "CLIENT_SECRET"),
+			RedirectURL:  os.Getenv(prefix + "REDIRECT_URL"),
+		})
 	}
 
+	return providers
 }
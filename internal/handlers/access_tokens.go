@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/theWebPartyTime/server/internal/models"
+	"github.com/theWebPartyTime/server/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AccessTokensHandler struct {
+	tokensService *service.AccessTokensService
+}
+
+func NewAccessTokensHandler(tokensService *service.AccessTokensService) *AccessTokensHandler {
+	return &AccessTokensHandler{tokensService: tokensService}
+}
+
+func (h *AccessTokensHandler) Tokens(c *gin.Context) {
+	u, ok := getUserFromContext(c)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "user not found in context"})
+		return
+	}
+
+	tokens, err := h.tokensService.GetUserTokens(c.Request.Context(), u.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, tokens)
+}
+
+// CreateToken responds with the token secret, the only time it is shown.
+func (h *AccessTokensHandler) CreateToken(c *gin.Context) {
+	var req models.CreateAccessToken
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	u, ok := getUserFromContext(c)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "user not found in context"})
+		return
+	}
+
+	token, secret, err := h.tokensService.CreateToken(c.Request.Context(), u.ID, req)
+	switch {
+	case errors.Is(err, service.ErrInvalidTokenName), errors.Is(err, service.ErrInvalidScope),
+		errors.Is(err, service.ErrInvalidExpiry), errors.Is(err, service.ErrTooManyTokens):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"token":        secret,
+		"access_token": token,
+	})
+}
+
+func (h *AccessTokensHandler) RevokeToken(c *gin.Context) {
+	tokenId, err := strconv.Atoi(c.Param("token_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid token id"})
+		return
+	}
+
+	u, ok := getUserFromContext(c)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "user not found in context"})
+		return
+	}
+
+	err = h.tokensService.RevokeToken(c.Request.Context(), u.ID, tokenId)
+	if errors.Is(err, service.ErrTokenNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,124 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/theWebPartyTime/server/internal/models"
+	"github.com/theWebPartyTime/server/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AdminHandler struct {
+	adminService *service.AdminService
+}
+
+func NewAdminHandler(adminService *service.AdminService) *AdminHandler {
+	return &AdminHandler{adminService: adminService}
+}
+
+func (h *AdminHandler) Users(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid limit parameter"})
+		return
+	}
+	offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid offset parameter"})
+		return
+	}
+
+	users, err := h.adminService.ListUsers(c.Request.Context(), limit, offset, c.Query("search"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, users)
+}
+
+func (h *AdminHandler) BanUser(c *gin.Context) {
+	var req models.BanRequest
+	if err := c.ShouldBindJSON(&req); err != nil && c.Request.ContentLength > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	h.moderateUser(c, func(ctx context.Context, actor *models.User, userId int) (*models.AdminUser, error) {
+		return h.adminService.BanUser(ctx, actor, userId, req.Reason)
+	})
+}
+
+func (h *AdminHandler) UnbanUser(c *gin.Context) {
+	h.moderateUser(c, h.adminService.UnbanUser)
+}
+
+func (h *AdminHandler) SetRole(c *gin.Context) {
+	var req models.RoleRequest
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	h.moderateUser(c, func(ctx context.Context, actor *models.User, userId int) (*models.AdminUser, error) {
+		return h.adminService.SetRole(ctx, actor, userId, req.Role)
+	})
+}
+
+func (h *AdminHandler) UnpublishScript(c *gin.Context) {
+	if err := h.adminService.UnpublishScript(c.Request.Context(), c.Param("script_hash")); err != nil {
+		respondAdminError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "script unpublished"})
+}
+
+func (h *AdminHandler) DeleteScript(c *gin.Context) {
+	if err := h.adminService.DeleteScript(c.Request.Context(), c.Param("script_hash")); err != nil {
+		respondAdminError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "script deleted"})
+}
+
+func (h *AdminHandler) moderateUser(c *gin.Context,
+	action func(ctx context.Context, actor *models.User, userId int) (*models.AdminUser, error)) {
+
+	userId, err := strconv.Atoi(c.Param("user_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+		return
+	}
+	u, ok := getUserFromContext(c)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "user not found in context"})
+		return
+	}
+
+	user, err := action(c.Request.Context(), u, userId)
+	if err != nil {
+		respondAdminError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
+
+func respondAdminError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrUserNotFound), errors.Is(err, service.ErrScriptNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrCannotModerate):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrInvalidRole), errors.Is(err, service.ErrCannotActOnSelf):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -1,28 +1,66 @@
 package handlers
 
 import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"log"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/theWebPartyTime/server/internal/auth"
 	"github.com/theWebPartyTime/server/internal/models"
+	"github.com/theWebPartyTime/server/internal/oidc"
+	"github.com/theWebPartyTime/server/internal/ratelimit"
 	"github.com/theWebPartyTime/server/internal/service"
 
 	"github.com/gin-gonic/gin"
 	go_jwt "github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 )
 
 type AuthHandler struct {
-	authService *service.AuthService
-	secretKey   []byte
+	authService     *service.AuthService
+	sessionsService *service.SessionsService
+	accountService  *service.AccountService
+	oidcService     *service.OIDCService
+	mfaService      *service.MFAService
+	guestsService   *service.GuestsService
+	limiter         *ratelimit.Limiter
+	keys            *auth.KeySet
 }
 
 const (
-	accessTTL  = time.Hour
-	refreshTTL = 7 * 24 * time.Hour
+	accessTTL = time.Hour
+	mfaTTL    = 5 * time.Minute
+	mailTTL   = 30 * time.Second
+
+	oidcStateCookie = "oidc_state"
+)
+
+var (
+	// loginFailures locks an account after 5 attempts in a row without a
+	// successful one, letting one more through every 3 minutes.
+	loginFailures = ratelimit.Limit{Burst: 5, Period: 15 * time.Minute}
+	resetEmails   = ratelimit.Limit{Burst: 3, Period: time.Hour}
+	// mfaTokenAttempts lets 3 codes be tried with an "mfa pending" token;
+	// the token expires long before it would let another one through.
+	mfaTokenAttempts = ratelimit.Limit{Burst: 3, Period: 24 * time.Hour}
 )
 
-func NewAuthHandler(authService *service.AuthService, secretKey []byte) *AuthHandler {
-	return &AuthHandler{authService: authService, secretKey: secretKey}
+func NewAuthHandler(authService *service.AuthService, sessionsService *service.SessionsService, accountService *service.AccountService, oidcService *service.OIDCService, mfaService *service.MFAService, guestsService *service.GuestsService, limiter *ratelimit.Limiter, keys *auth.KeySet) *AuthHandler {
+	return &AuthHandler{
+		authService:     authService,
+		sessionsService: sessionsService,
+		accountService:  accountService,
+		oidcService:     oidcService,
+		mfaService:      mfaService,
+		guestsService:   guestsService,
+		limiter:         limiter,
+		keys:            keys,
+	}
 }
 
 func (h *AuthHandler) GetAuthService() *service.AuthService {
@@ -36,70 +74,248 @@ func (h *AuthHandler) Login(c *gin.Context) {
 		return
 	}
 
-	user, err := h.authService.Login(c.Request.Context(), req)
+	// Every attempt takes a token up front, so that concurrent guesses can
+	// not all get through; a successful one gives them back.
+	ctx := c.Request.Context()
+	key := accountKey("login", req.Email)
+	if result := h.limiter.Take(ctx, key, loginFailures); !result.Allowed {
+		ratelimit.Reject(c, result)
+		return
+	}
+
+	user, err := h.authService.Login(ctx, req)
 	if err != nil {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid credentials"})
 		return
 	}
 
-	accessToken, err := h.generateToken(user, accessTTL, "access")
+	h.limiter.Reset(ctx, key)
+	h.completeLogin(c, user)
+}
+
+func (h *AuthHandler) Register(c *gin.Context) {
+	var req models.RegisterRequest
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, err := h.authService.Register(c.Request.Context(), req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate access token"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	refreshToken, err := h.generateToken(user, refreshTTL, "refresh")
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), mailTTL)
+		defer cancel()
+
+		if err := h.accountService.SendVerification(ctx, user); err != nil {
+			log.Println(err.Error())
+		}
+	}()
+
+	h.startSession(c, http.StatusCreated, user)
+}
+
+// OIDCStart sends the user to log in with an external provider. The state
+// is also kept in a cookie, so that the login can only be finished by the
+// browser that started it.
+func (h *AuthHandler) OIDCStart(c *gin.Context) {
+	url, state, err := h.oidcService.Start(c.Request.Context(), c.Param("provider"))
+	if errors.Is(err, service.ErrUnknownProvider) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate refresh token"})
+		log.Println(err.Error())
+		c.JSON(http.StatusBadGateway, gin.H{"error": "login provider is unavailable"})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"user": gin.H{
-			"id":    user.ID,
-			"email": user.Email,
-		},
-		"access_token":  accessToken,
-		"refresh_token": refreshToken,
+	setOIDCState(c, state, int(oidc.FlowTTL.Seconds()))
+	c.Redirect(http.StatusFound, url)
+}
+
+// OIDCCallback is where the provider sends the user back to. It responds
+// like Login.
+func (h *AuthHandler) OIDCCallback(c *gin.Context) {
+	state, _ := c.Cookie(oidcStateCookie)
+	setOIDCState(c, "", -1)
+
+	if providerError := c.Query("error"); providerError != "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": providerError})
+		return
+	}
+
+	if state == "" || subtle.ConstantTimeCompare([]byte(state), []byte(c.Query("state"))) != 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": service.ErrInvalidOIDCState.Error()})
+		return
+	}
+
+	user, err := h.oidcService.Callback(c.Request.Context(), c.Param("provider"), c.Query("code"), c.Query("state"))
+	switch {
+	case errors.Is(err, service.ErrUnknownProvider):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, service.ErrInvalidOIDCState), errors.Is(err, service.ErrOIDCEmailMissing):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, service.ErrOIDCEmailNotLinked):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, service.ErrOIDCEmailNotVerified):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	case err != nil:
+		log.Println(err.Error())
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "login failed"})
+		return
+	}
+
+	h.completeLogin(c, user)
+}
+
+// setOIDCState keeps the state of a started login in the browser, or drops
+// it when maxAge is negative. It is sent back along with the provider's
+// redirect, which is a cross-site navigation, so it can not be SameSite=Strict.
+func setOIDCState(c *gin.Context, state string, maxAge int) {
+	http.SetCookie(c.Writer, &http.Cookie{
+		Name:     oidcStateCookie,
+		Value:    state,
+		Path:     "/auth/oidc/",
+		MaxAge:   maxAge,
+		Secure:   true,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
 	})
 }
 
-func (h *AuthHandler) Register(c *gin.Context) {
-	var req models.RegisterRequest
+func (h *AuthHandler) VerifyEmail(c *gin.Context) {
+	var req struct {
+		Token string `json:"token"`
+	}
 	if err := c.BindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	user, err := h.authService.Register(c.Request.Context(), req)
+	err := h.accountService.VerifyEmail(c.Request.Context(), req.Token)
+	if errors.Is(err, service.ErrInvalidAccountToken) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (h *AuthHandler) ResendVerification(c *gin.Context) {
+	u, ok := getUserFromContext(c)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "user not found in context"})
+		return
+	}
+
+	err := h.accountService.ResendVerification(c.Request.Context(), u.ID)
+	if errors.Is(err, service.ErrAlreadyVerified) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
 	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to send verification email"})
+		return
+	}
+
+	c.Status(http.StatusAccepted)
+}
+
+func (h *AuthHandler) ForgotPassword(c *gin.Context) {
+	var req models.ForgotPasswordRequest
+	if err := c.BindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	accessToken, err := h.generateToken(user, accessTTL, "access")
+	result := h.limiter.Take(c.Request.Context(), accountKey("forgot", req.Email), resetEmails)
+	if !result.Allowed {
+		ratelimit.Reject(c, result)
+		return
+	}
+
+	// The mail goes out in the background, so that neither the time taken
+	// nor a failure to send tells whether the account exists.
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), mailTTL)
+		defer cancel()
+
+		if err := h.accountService.ForgotPassword(ctx, req.Email); err != nil {
+			log.Println(err.Error())
+		}
+	}()
+
+	c.Status(http.StatusAccepted)
+}
+
+func (h *AuthHandler) ResetPassword(c *gin.Context) {
+	var req models.ResetPasswordRequest
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	err := h.accountService.ResetPassword(c.Request.Context(), req)
+	if errors.Is(err, service.ErrInvalidAccountToken) || errors.Is(err, service.ErrEmptyPassword) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate access token"})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (h *AuthHandler) RefreshToken(c *gin.Context) {
+	var req struct {
+		RefreshToken string `json:"refresh_token"`
+	}
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	refreshToken, err := h.generateToken(user, refreshTTL, "refresh")
+	user, session, refreshToken, err := h.sessionsService.Refresh(c.Request.Context(), req.RefreshToken)
+	if errors.Is(err, service.ErrUserBanned) {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
+	if errors.Is(err, service.ErrInvalidRefreshToken) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid refresh token"})
+		return
+	}
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate refresh token"})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to refresh session"})
 		return
 	}
 
-	c.JSON(http.StatusCreated, gin.H{
-		"user": gin.H{
-			"id":    user.ID,
-			"email": user.Email,
-		},
+	accessToken, err := h.generateToken(user, session.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate access token"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
 		"access_token":  accessToken,
 		"refresh_token": refreshToken,
 	})
 }
 
-func (h *AuthHandler) RefreshToken(c *gin.Context) {
+func (h *AuthHandler) Logout(c *gin.Context) {
 	var req struct {
 		RefreshToken string `json:"refresh_token"`
 	}
@@ -108,48 +324,312 @@ func (h *AuthHandler) RefreshToken(c *gin.Context) {
 		return
 	}
 
-	token, err := go_jwt.Parse(req.RefreshToken, func(t *go_jwt.Token) (interface{}, error) {
-		if t.Method != go_jwt.SigningMethodHS256 {
-			return nil, go_jwt.ErrSignatureInvalid
-		}
-		return h.secretKey, nil
+	err := h.sessionsService.Logout(c.Request.Context(), req.RefreshToken)
+	if err != nil && !errors.Is(err, service.ErrInvalidRefreshToken) {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to log out"})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (h *AuthHandler) Sessions(c *gin.Context) {
+	u, ok := getUserFromContext(c)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "user not found in context"})
+		return
+	}
+
+	sessions, err := h.sessionsService.GetUserSessions(c.Request.Context(), u.ID, c.GetString("session"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, sessions)
+}
+
+func (h *AuthHandler) RevokeSession(c *gin.Context) {
+	u, ok := getUserFromContext(c)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "user not found in context"})
+		return
+	}
+
+	err := h.sessionsService.RevokeSession(c.Request.Context(), u.ID, c.Param("session_id"))
+	if errors.Is(err, service.ErrSessionNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// RevokeOtherSessions logs the user out of every device but the one making
+// the request.
+func (h *AuthHandler) RevokeOtherSessions(c *gin.Context) {
+	u, ok := getUserFromContext(c)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "user not found in context"})
+		return
+	}
+
+	if err := h.sessionsService.RevokeOtherSessions(c.Request.Context(), u.ID, c.GetString("session")); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func respondBanned(c *gin.Context, user *models.User) {
+	c.JSON(http.StatusForbidden, gin.H{
+		"error":  service.ErrUserBanned.Error(),
+		"reason": user.BanReason,
+	})
+}
+
+func accountKey(name string, email string) string {
+	return name + ":account:" + strings.ToLower(strings.TrimSpace(email))
+}
+
+// completeLogin starts a session, unless the user has two-factor
+// authentication on: then they get an "mfa pending" token that MFA exchanges
+// for a session along with a code.
+func (h *AuthHandler) completeLogin(c *gin.Context, user *models.User) {
+	if user.TOTPEnabledAt == nil || user.BannedAt != nil {
+		h.startSession(c, http.StatusOK, user)
+		return
+	}
+
+	now := time.Now()
+	mfaToken, err := h.keys.Sign(go_jwt.MapClaims{
+		"id":  user.ID,
+		"iat": now.Unix(),
+		"exp": now.Add(mfaTTL).Unix(),
+		"jti": uuid.NewString(),
+		"typ": "mfa_pending",
 	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate mfa token"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"mfa_required": true,
+		"mfa_token":    mfaToken,
+	})
+}
+
+func (h *AuthHandler) MFA(c *gin.Context) {
+	var req models.MFARequest
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	token, err := h.keys.Parse(req.MFAToken)
 	if err != nil || !token.Valid {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid refresh token"})
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid mfa token"})
 		return
 	}
 
 	claims, ok := token.Claims.(go_jwt.MapClaims)
-	if !ok || claims["typ"] != "refresh" {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token claims"})
+	id, isNumber := claims["id"].(float64)
+	tokenId, _ := claims["jti"].(string)
+	if !ok || !isNumber || tokenId == "" || claims["typ"] != "mfa_pending" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid mfa token"})
+		return
+	}
+
+	// Attempts are taken before the code is checked, like logins, both from
+	// the pending token and from the account.
+	ctx := c.Request.Context()
+	if result := h.limiter.Take(ctx, "mfa:token:"+tokenId, mfaTokenAttempts); !result.Allowed {
+		ratelimit.Reject(c, result)
+		return
+	}
+
+	key := "mfa:account:" + strconv.Itoa(int(id))
+	if result := h.limiter.Take(ctx, key, loginFailures); !result.Allowed {
+		ratelimit.Reject(c, result)
+		return
+	}
+
+	user, err := h.mfaService.VerifyLogin(ctx, int(id), req.Code)
+	if errors.Is(err, service.ErrInvalidMFACode) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid mfa token"})
+		return
+	}
+
+	h.limiter.Reset(ctx, key)
+	h.startSession(c, http.StatusOK, user)
+}
+
+func (h *AuthHandler) EnrollMFA(c *gin.Context) {
+	u, ok := getUserFromContext(c)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "user not found in context"})
+		return
+	}
+
+	enrollment, err := h.mfaService.Enroll(c.Request.Context(), u.ID)
+	if err != nil {
+		respondMFAError(c, err)
 		return
 	}
 
-	user := &models.User{
-		ID:    int(claims["id"].(float64)),
-		Email: claims["email"].(string),
+	c.JSON(http.StatusOK, enrollment)
+}
+
+func (h *AuthHandler) ConfirmMFA(c *gin.Context) {
+	h.mfaAction(c, h.mfaService.Confirm)
+}
+
+func (h *AuthHandler) DisableMFA(c *gin.Context) {
+	h.mfaAction(c, h.mfaService.Disable)
+}
+
+func (h *AuthHandler) mfaAction(c *gin.Context, action func(ctx context.Context, userId int, code string) error) {
+	var req struct {
+		Code string `json:"code"`
+	}
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
 
-	accessToken, err := h.generateToken(user, accessTTL, "access")
+	u, ok := getUserFromContext(c)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "user not found in context"})
+		return
+	}
+
+	if err := action(c.Request.Context(), u.ID, req.Code); err != nil {
+		respondMFAError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func respondMFAError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrMFAAlreadyEnabled), errors.Is(err, service.ErrMFANotEnrolled):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrInvalidMFACode):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrUserNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+func (h *AuthHandler) startSession(c *gin.Context, status int, user *models.User) {
+	session, refreshToken, err := h.sessionsService.StartSession(c.Request.Context(), user,
+		c.Request.UserAgent(), c.ClientIP())
+	if errors.Is(err, service.ErrUserBanned) {
+		respondBanned(c, user)
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to start session"})
+		return
+	}
+
+	accessToken, err := h.generateToken(user, session.ID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate access token"})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"access_token": accessToken,
+	response := gin.H{
+		"user": gin.H{
+			"id":    user.ID,
+			"email": user.Email,
+		},
+		"access_token":  accessToken,
+		"refresh_token": refreshToken,
+	}
+
+	if merged, ok := h.mergeGuest(c, user); ok {
+		response["merged_parties"] = merged
+	}
+
+	c.JSON(status, response)
+}
+
+// Guest hands out a guest token to play with without an account. Presenting
+// it as the X-Guest-Token header when registering or logging in later moves
+// the history of the guest to the account.
+func (h *AuthHandler) Guest(c *gin.Context) {
+	guest, err := h.guestsService.CreateGuest(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create guest"})
+		return
+	}
+
+	guestToken, err := h.keys.GuestToken(guest.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate guest token"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"guest_id":    guest.ID,
+		"guest_token": guestToken,
 	})
 }
 
-func (h *AuthHandler) generateToken(user *models.User, ttl time.Duration, typ string) (string, error) {
+// mergeGuest merges the guest of the X-Guest-Token header, if any, into the
+// account of user. Failing to merge does not fail the login.
+func (h *AuthHandler) mergeGuest(c *gin.Context, user *models.User) (int, bool) {
+	guestToken := c.GetHeader("X-Guest-Token")
+	if guestToken == "" {
+		return 0, false
+	}
+
+	guestId, err := h.keys.ParseGuestToken(guestToken)
+	if err != nil {
+		return 0, false
+	}
+
+	merged, err := h.guestsService.Merge(c.Request.Context(), guestId, user.ID)
+	if err != nil {
+		if !errors.Is(err, service.ErrGuestAlreadyMerged) {
+			log.Println(err.Error())
+		}
+		return 0, false
+	}
+
+	return merged, true
+}
+
+func (h *AuthHandler) generateToken(user *models.User, sessionId string) (string, error) {
 	now := time.Now()
 	claims := go_jwt.MapClaims{
 		"id":    user.ID,
 		"email": user.Email,
+		"sid":   sessionId,
+		"role":  user.Role,
 		"iat":   now.Unix(),
-		"exp":   now.Add(ttl).Unix(),
-		"typ":   typ,
+		"exp":   now.Add(accessTTL).Unix(),
+		"typ":   "access",
 	}
-	token := go_jwt.NewWithClaims(go_jwt.SigningMethodHS256, claims)
-	return token.SignedString(h.secretKey)
+	return h.keys.Sign(claims)
+}
+
+// JWKS serves the keys access tokens can be verified with.
+func (h *AuthHandler) JWKS(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, h.keys.JWKS())
 }
@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/theWebPartyTime/server/internal/models"
+	"github.com/theWebPartyTime/server/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type LeaderboardsHandler struct {
+	leaderboardsService *service.LeaderboardsService
+}
+
+func NewLeaderboardsHandler(leaderboardsService *service.LeaderboardsService) *LeaderboardsHandler {
+	return &LeaderboardsHandler{leaderboardsService: leaderboardsService}
+}
+
+// Leaderboard lists the best score of every registered player of a script.
+// period is all, week or month; version narrows it down to a single script
+// hash.
+func (h *LeaderboardsHandler) Leaderboard(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid limit parameter"})
+		return
+	}
+	offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid offset parameter"})
+		return
+	}
+	u, ok := getUserFromContext(c)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "user not found in context"})
+		return
+	}
+
+	leaderboard, err := h.leaderboardsService.GetLeaderboard(c.Request.Context(), c.Param("script_hash"), u.ID,
+		models.LeaderboardQuery{
+			Period:     models.LeaderboardPeriod(c.DefaultQuery("period", "all")),
+			ScriptHash: c.Query("version"),
+			Limit:      limit,
+			Offset:     offset,
+		})
+	if err != nil {
+		respondLeaderboardError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"leaderboard": leaderboard})
+}
+
+func (h *LeaderboardsHandler) ResetPlayer(c *gin.Context) {
+	h.moderate(c, h.leaderboardsService.ResetPlayer, "entries reset")
+}
+
+func (h *LeaderboardsHandler) ExcludePlayer(c *gin.Context) {
+	h.moderate(c, h.leaderboardsService.ExcludePlayer, "player excluded")
+}
+
+func (h *LeaderboardsHandler) IncludePlayer(c *gin.Context) {
+	h.moderate(c, h.leaderboardsService.IncludePlayer, "player included")
+}
+
+func (h *LeaderboardsHandler) moderate(c *gin.Context,
+	action func(ctx context.Context, scriptHash string, ownerId int, playerId int) error, status string) {
+
+	playerId, err := strconv.Atoi(c.Param("user_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+		return
+	}
+	u, ok := getUserFromContext(c)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "user not found in context"})
+		return
+	}
+
+	if err := action(c.Request.Context(), c.Param("script_hash"), u.ID, playerId); err != nil {
+		respondLeaderboardError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": status})
+}
+
+func respondLeaderboardError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrScriptNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "script not found"})
+	case errors.Is(err, service.ErrNotScriptOwner):
+		c.JSON(http.StatusForbidden, gin.H{"error": "forbidden"})
+	case errors.Is(err, service.ErrInvalidPeriod):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		log.Println(err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "error retrieving data from the database"})
+	}
+}
@@ -0,0 +1,270 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/theWebPartyTime/server/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type PartiesHandler struct {
+	partiesService *service.PartiesService
+	eventsService  *service.EventsService
+}
+
+func NewPartiesHandler(partiesService *service.PartiesService, eventsService *service.EventsService) *PartiesHandler {
+	return &PartiesHandler{partiesService: partiesService, eventsService: eventsService}
+}
+
+// UserParties lists the parties the user hosted or played in, latest first.
+func (h *PartiesHandler) UserParties(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid limit parameter"})
+		return
+	}
+	offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid offset parameter"})
+		return
+	}
+	u, ok := getUserFromContext(c)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "user not found in context"})
+		return
+	}
+
+	parties, err := h.partiesService.GetUserParties(c.Request.Context(), u.ID, limit, offset)
+	if err != nil {
+		log.Println(err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "error retrieving data from the database",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"parties": parties,
+	})
+}
+
+func (h *PartiesHandler) Party(c *gin.Context) {
+	partyId, err := strconv.Atoi(c.Param("party_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid party id"})
+		return
+	}
+	u, ok := getUserFromContext(c)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "user not found in context"})
+		return
+	}
+
+	party, err := h.partiesService.GetParty(c.Request.Context(), partyId, u.ID)
+	if errors.Is(err, service.ErrPartyNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		log.Println(err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "error retrieving data from the database",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"party": party,
+	})
+}
+
+// PartyEvents steps through the event log of a party, from the from
+// sequence number on.
+func (h *PartiesHandler) PartyEvents(c *gin.Context) {
+	partyId, err := strconv.Atoi(c.Param("party_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid party id"})
+		return
+	}
+	from, err := strconv.Atoi(c.DefaultQuery("from", "0"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid from parameter"})
+		return
+	}
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "100"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid limit parameter"})
+		return
+	}
+	u, ok := getUserFromContext(c)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "user not found in context"})
+		return
+	}
+
+	events, err := h.eventsService.GetPartyEvents(c.Request.Context(), partyId, u.ID, from, limit)
+	if errors.Is(err, service.ErrPartyNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		log.Println(err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "error retrieving data from the database",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"events": events,
+	})
+}
+
+// PartyResults exports one row per player per step of a party, as JSON or
+// as CSV with format=csv. Only the host can export results.
+func (h *PartiesHandler) PartyResults(c *gin.Context) {
+	partyId, err := strconv.Atoi(c.Param("party_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid party id"})
+		return
+	}
+	format := c.DefaultQuery("format", "json")
+	if format != "json" && format != "csv" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format must be csv or json"})
+		return
+	}
+	u, ok := getUserFromContext(c)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "user not found in context"})
+		return
+	}
+
+	results, err := h.partiesService.GetResults(c.Request.Context(), partyId, u.ID)
+	if errors.Is(err, service.ErrPartyNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if errors.Is(err, service.ErrNotPartyHost) {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		log.Println(err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "error retrieving data from the database",
+		})
+		return
+	}
+
+	if format == "json" {
+		c.JSON(http.StatusOK, gin.H{
+			"results": results,
+		})
+		return
+	}
+
+	c.Header("Content-Type", "text/csv")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=party-%d-results.csv", partyId))
+	c.Status(http.StatusOK)
+
+	writer := csv.NewWriter(c.Writer)
+	writer.Write([]string{"nickname", "step", "query", "answer", "correct", "points", "response_ms", "rank"})
+
+	for _, result := range results {
+		responseMs := ""
+		if result.ResponseMs != nil {
+			responseMs = strconv.FormatInt(*result.ResponseMs, 10)
+		}
+
+		writer.Write([]string{
+			csvText(result.Nickname),
+			strconv.Itoa(result.Step),
+			csvText(result.Query),
+			csvText(result.Answer),
+			strconv.FormatBool(result.Correct),
+			strconv.Itoa(result.Points),
+			responseMs,
+			strconv.Itoa(result.Rank),
+		})
+	}
+
+	writer.Flush()
+}
+
+// csvText keeps text players typed from being run as a formula by
+// spreadsheets opening the export.
+func csvText(text string) string {
+	if text != "" && strings.ContainsRune("=+-@\t\r", rune(text[0])) {
+		return "'" + text
+	}
+
+	return text
+}
+
+// ScriptAnalytics aggregates every party of a script for its owner. The
+// from and to query parameters take RFC 3339 timestamps or dates, to being
+// inclusive for dates.
+func (h *PartiesHandler) ScriptAnalytics(c *gin.Context) {
+	from, err := parseDateParam(c.Query("from"), false)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid from parameter"})
+		return
+	}
+	to, err := parseDateParam(c.Query("to"), true)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid to parameter"})
+		return
+	}
+	if to.IsZero() {
+		to = time.Now().Add(24 * time.Hour)
+	}
+	u, ok := getUserFromContext(c)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "user not found in context"})
+		return
+	}
+
+	analytics, err := h.partiesService.GetScriptAnalytics(c.Request.Context(), c.Param("script_hash"), u.ID, from, to)
+	if errors.Is(err, service.ErrScriptNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if errors.Is(err, service.ErrNotScriptOwner) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "forbidden"})
+		return
+	}
+	if err != nil {
+		log.Println(err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "error retrieving data from the database",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"analytics": analytics,
+	})
+}
+
+func parseDateParam(value string, endOfDay bool) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	if date, err := time.Parse(time.DateOnly, value); err == nil {
+		if endOfDay {
+			date = date.Add(24 * time.Hour)
+		}
+		return date, nil
+	}
+
+	return time.Parse(time.RFC3339, value)
+}
@@ -0,0 +1,175 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/theWebPartyTime/server/internal/models"
+	"github.com/theWebPartyTime/server/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ProfilesHandler struct {
+	profilesService     *service.ProfilesService
+	achievementsService *service.AchievementsService
+	accountDataService  *service.AccountDataService
+}
+
+func NewProfilesHandler(profilesService *service.ProfilesService, achievementsService *service.AchievementsService, accountDataService *service.AccountDataService) *ProfilesHandler {
+	return &ProfilesHandler{
+		profilesService:     profilesService,
+		achievementsService: achievementsService,
+		accountDataService:  accountDataService,
+	}
+}
+
+func (h *ProfilesHandler) Me(c *gin.Context) {
+	u, ok := getUserFromContext(c)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "user not found in context"})
+		return
+	}
+
+	profile, err := h.profilesService.GetProfile(c.Request.Context(), u.ID, true)
+	if err != nil {
+		respondProfileError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"profile": profile})
+}
+
+// UpdateMe accepts JSON or a multipart form; the avatar can only be sent
+// with the latter.
+func (h *ProfilesHandler) UpdateMe(c *gin.Context) {
+	var req models.UpdateProfile
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	u, ok := getUserFromContext(c)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "user not found in context"})
+		return
+	}
+
+	avatarFile, err := c.FormFile("avatar")
+	if err == nil && avatarFile != nil {
+		f, err := avatarFile.Open()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		defer f.Close()
+		req.AvatarFile = f
+	}
+
+	profile, err := h.profilesService.UpdateProfile(c.Request.Context(), u.ID, req)
+	if err != nil {
+		respondProfileError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"profile": profile})
+}
+
+func (h *ProfilesHandler) Profile(c *gin.Context) {
+	userId, err := strconv.Atoi(c.Param("user_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+		return
+	}
+
+	profile, err := h.profilesService.GetProfile(c.Request.Context(), userId, false)
+	if err != nil {
+		respondProfileError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"profile": profile})
+}
+
+func (h *ProfilesHandler) Achievements(c *gin.Context) {
+	userId, err := strconv.Atoi(c.Param("user_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+		return
+	}
+
+	achievements, err := h.achievementsService.GetUserAchievements(c.Request.Context(), userId)
+	if err != nil {
+		respondProfileError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"achievements": achievements})
+}
+
+// Export hands out everything kept about the user as a JSON file.
+func (h *ProfilesHandler) Export(c *gin.Context) {
+	u, ok := getUserFromContext(c)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "user not found in context"})
+		return
+	}
+
+	export, err := h.accountDataService.Export(c.Request.Context(), u.ID)
+	if err != nil {
+		respondProfileError(c, err)
+		return
+	}
+
+	filename := "webpartytime-export-" + export.ExportedAt.Format("2006-01-02") + ".json"
+	c.Header("Content-Disposition", `attachment; filename="`+filename+`"`)
+	c.IndentedJSON(http.StatusOK, export)
+}
+
+func (h *ProfilesHandler) DeleteMe(c *gin.Context) {
+	var req models.DeleteAccountRequest
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	u, ok := getUserFromContext(c)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "user not found in context"})
+		return
+	}
+
+	err := h.accountDataService.DeleteAccount(c.Request.Context(), u.ID, c.GetString("session"), req)
+	switch {
+	case errors.Is(err, service.ErrInvalidPassword), errors.Is(err, service.ErrInvalidMFACode),
+		errors.Is(err, service.ErrLoginNotRecent):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, service.ErrInvalidScriptsPolicy), errors.Is(err, service.ErrTransferTarget):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	case err != nil:
+		respondProfileError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func respondProfileError(c *gin.Context, err error) {
+	if errors.Is(err, service.ErrUserNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	if errors.Is(err, service.ErrDisplayNameTooLong) || errors.Is(err, service.ErrInvalidLanguage) ||
+		errors.Is(err, service.ErrAvatarNotImage) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	log.Println(err.Error())
+	c.JSON(http.StatusInternalServerError, gin.H{"error": "error retrieving data from the database"})
+}
@@ -2,6 +2,8 @@ package handlers
 
 import (
 	"bytes"
+	"errors"
+	"fmt"
 	"io"
 	"log"
 	"net/http"
@@ -11,10 +13,13 @@ import (
 
 	"github.com/theWebPartyTime/server/internal/models"
 	"github.com/theWebPartyTime/server/internal/service"
+	"github.com/theWebPartyTime/server/internal/simulation"
 
 	"github.com/gin-gonic/gin"
 )
 
+const maxSimulationBots = 50
+
 type ScriptsHandler struct {
 	scriptsService *service.ScriptsService
 }
@@ -164,8 +169,13 @@ func (h *ScriptsHandler) UploadScript(c *gin.Context) {
 	}
 
 	scriptRequest.CreatorId = u.ID
+	scriptRequest.RequireTests, _ = strconv.ParseBool(c.PostForm("require_tests"))
 
-	h.scriptsService.UploadScript(c.Request.Context(), scriptRequest)
+	err = h.scriptsService.UploadScript(c.Request.Context(), scriptRequest)
+	if err != nil {
+		respondScriptError(c, err)
+		return
+	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Script uploaded successfully",
@@ -265,17 +275,20 @@ func (h *ScriptsHandler) UpdateScript(c *gin.Context) {
 		public = script.Public
 	}
 
+	requireTests, _ := strconv.ParseBool(c.PostForm("require_tests"))
+
 	updateRequest = models.UpdateScript{
-		ScriptFile:  scriptReader,
-		CoverFile:   coverReader,
-		Title:       title,
-		Description: description,
-		Public:      public,
+		ScriptFile:   scriptReader,
+		CoverFile:    coverReader,
+		Title:        title,
+		Description:  description,
+		Public:       public,
+		RequireTests: requireTests,
 	}
 
 	err = h.scriptsService.UpdateScript(c.Request.Context(), script.ScriptHash, script.CoverHash, updateRequest)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondScriptError(c, err)
 		return
 	}
 
@@ -283,6 +296,137 @@ func (h *ScriptsHandler) UpdateScript(c *gin.Context) {
 
 }
 
+func (h *ScriptsHandler) SimulateScript(c *gin.Context) {
+	var req models.SimulateScript
+	if err := c.ShouldBindJSON(&req); err != nil && err != io.EOF {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	scriptHash := c.Param("script_hash")
+	script, err := h.scriptsService.GetScriptByHash(c.Request.Context(), scriptHash)
+	if err != nil {
+		log.Println(err.Error())
+		c.JSON(http.StatusNotFound, gin.H{"error": "script not found"})
+		return
+	}
+
+	u, ok := getUserFromContext(c)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "user not found in context"})
+		return
+	}
+
+	if !script.Public && script.CreatorId != u.ID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "forbidden"})
+		return
+	}
+
+	config, err := simulationConfig(req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	trace, err := h.scriptsService.SimulateScript(c.Request.Context(), scriptHash, config)
+	if err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"trace": trace})
+}
+
+func (h *ScriptsHandler) TestScript(c *gin.Context) {
+	scriptHash := c.Param("script_hash")
+	script, err := h.scriptsService.GetScriptByHash(c.Request.Context(), scriptHash)
+	if err != nil {
+		log.Println(err.Error())
+		c.JSON(http.StatusNotFound, gin.H{"error": "script not found"})
+		return
+	}
+
+	u, ok := getUserFromContext(c)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "user not found in context"})
+		return
+	}
+
+	if !script.Public && script.CreatorId != u.ID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "forbidden"})
+		return
+	}
+
+	report, err := h.scriptsService.TestScript(c.Request.Context(), scriptHash)
+	if err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"passed":               simulation.Passed(report.Results),
+		"tests":                report.Results,
+		"missing_translations": report.MissingTranslations,
+	})
+}
+
+func respondScriptError(c *gin.Context, err error) {
+	var testsFailed *service.TestsFailedError
+	if errors.As(err, &testsFailed) {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"error": err.Error(),
+			"tests": testsFailed.Results,
+		})
+		return
+	}
+
+	if errors.Is(err, service.ErrEmailNotVerified) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "verify your email address to publish public scripts"})
+		return
+	}
+
+	c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+}
+
+func simulationConfig(req models.SimulateScript) (simulation.Config, error) {
+	config := simulation.DefaultConfig()
+
+	strategies := req.Strategies
+	if len(strategies) == 0 {
+		if req.Strategy == "" {
+			req.Strategy = string(simulation.Random)
+		}
+		if req.Bots == 0 {
+			req.Bots = len(config.Bots)
+		}
+		for i := 0; i < req.Bots; i++ {
+			strategies = append(strategies, req.Strategy)
+		}
+	}
+
+	if len(strategies) > maxSimulationBots {
+		return config, fmt.Errorf("at most %d bots can be simulated", maxSimulationBots)
+	}
+
+	config.Bots = simulation.NewBots(len(strategies), simulation.Random)
+	for i, strategy := range strategies {
+		config.Bots[i].Strategy = simulation.Strategy(strategy)
+		if !config.Bots[i].Strategy.IsValid() {
+			return config, fmt.Errorf("unknown bot strategy %q", strategy)
+		}
+	}
+
+	if req.Seed != 0 {
+		config.Seed = req.Seed
+	}
+	if req.MaxSteps > 0 && req.MaxSteps < config.MaxSteps {
+		config.MaxSteps = req.MaxSteps
+	}
+	config.Language = req.Language
+
+	return config, nil
+}
+
 func getUserFromContext(c *gin.Context) (*models.User, bool) {
 	u, ok := c.Get("user")
 	if !ok {
@@ -0,0 +1,39 @@
+package file
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/theWebPartyTime/server/internal/mailer"
+)
+
+type FileMailer struct {
+	baseDir string
+}
+
+// NewFileMailer writes every message to a file in baseDir instead of
+// delivering it. With an empty baseDir messages are only logged.
+func NewFileMailer(baseDir string) mailer.Mailer {
+	return &FileMailer{baseDir: baseDir}
+}
+
+func (m *FileMailer) Send(ctx context.Context, message mailer.Message) error {
+	text := fmt.Sprintf("To: %s\nSubject: %s\n\n%s\n", message.To, message.Subject, message.Body)
+
+	if m.baseDir == "" {
+		log.Printf("mail:\n%s", text)
+		return nil
+	}
+
+	if err := os.MkdirAll(m.baseDir, 0o755); err != nil {
+		return err
+	}
+
+	name := fmt.Sprintf("%s-%s.eml", time.Now().Format("20060102T150405"), uuid.NewString())
+	return os.WriteFile(filepath.Join(m.baseDir, name), []byte(text), 0o644)
+}
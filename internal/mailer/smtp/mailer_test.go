@@ -0,0 +1,96 @@
+package smtp
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/theWebPartyTime/server/internal/mailer"
+)
+
+// fakeServer accepts a single message and hands its data to the returned
+// channel.
+func fakeServer(t *testing.T) (string, <-chan string) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	data := make(chan string, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		reader := bufio.NewReader(conn)
+		reply := func(line string) { conn.Write([]byte(line + "\r\n")) }
+
+		reply("220 localhost ready")
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				return
+			}
+
+			switch command := strings.ToUpper(strings.TrimSpace(line)); {
+			case strings.HasPrefix(command, "EHLO"), strings.HasPrefix(command, "HELO"):
+				reply("250 localhost")
+			case command == "DATA":
+				reply("354 go ahead")
+
+				var message strings.Builder
+				for {
+					line, err := reader.ReadString('\n')
+					if err != nil {
+						return
+					}
+					if line == ".\r\n" {
+						break
+					}
+					message.WriteString(line)
+				}
+				data <- message.String()
+				reply("250 queued")
+			case command == "QUIT":
+				reply("221 bye")
+				return
+			default:
+				reply("250 ok")
+			}
+		}
+	}()
+
+	return listener.Addr().String(), data
+}
+
+func TestSMTPMailerSend(t *testing.T) {
+	addr, data := fakeServer(t)
+	host, port, _ := net.SplitHostPort(addr)
+
+	m := NewSMTPMailer(host, port, "", "", "party@example.com")
+	err := m.Send(context.Background(), mailer.Message{
+		To:      "player@example.com",
+		Subject: "Hello",
+		Body:    "line one\nline two",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	message := <-data
+	for _, want := range []string{
+		"From: party@example.com\r\n",
+		"To: player@example.com\r\n",
+		"Subject: Hello\r\n",
+		"\r\n\r\nline one\r\nline two",
+	} {
+		if !strings.Contains(message, want) {
+			t.Errorf("message %q does not contain %q", message, want)
+		}
+	}
+}
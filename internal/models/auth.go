@@ -1 +1,113 @@
 package models
+
+import "time"
+
+// Session is a device a user is logged in on. Every refresh rotates its
+// refresh token; all tokens of a session form one family.
+type Session struct {
+	ID         string     `json:"id"`
+	UserId     int        `json:"-"`
+	UserAgent  string     `json:"user_agent"`
+	IP         string     `json:"ip"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt time.Time  `json:"last_used_at"`
+	RevokedAt  *time.Time `json:"-"`
+	Current    bool       `json:"current" gorm:"-"`
+}
+
+// RefreshToken is stored hashed only; UsedAt is set once it was exchanged.
+type RefreshToken struct {
+	ID        int
+	SessionId string
+	TokenHash string
+	CreatedAt time.Time
+	ExpiresAt time.Time
+	UsedAt    *time.Time
+}
+
+type AccountTokenPurpose string
+
+const (
+	VerifyEmail   AccountTokenPurpose = "verify_email"
+	ResetPassword AccountTokenPurpose = "reset_password"
+)
+
+// AccountToken is a single-use token mailed to a user to prove they own
+// their email address.
+type AccountToken struct {
+	ID        int
+	UserId    int
+	Purpose   AccountTokenPurpose
+	TokenHash string
+	CreatedAt time.Time
+	ExpiresAt time.Time
+	UsedAt    *time.Time
+}
+
+// UserIdentity links an account of an OIDC provider to a user.
+type UserIdentity struct {
+	ID        int
+	UserId    int
+	Provider  string
+	Subject   string
+	Email     string
+	CreatedAt time.Time
+}
+
+const (
+	ScopeScriptsRead  = "scripts:read"
+	ScopeScriptsWrite = "scripts:write"
+)
+
+// Scopes lists what personal access tokens can be allowed to do.
+var Scopes = []string{ScopeScriptsRead, ScopeScriptsWrite}
+
+// PersonalAccessToken lets scripts act as a user without their password.
+// Prefix is the start of the token, kept so that users can tell their tokens
+// apart; the token itself is only stored hashed.
+type PersonalAccessToken struct {
+	ID         int        `json:"id"`
+	UserId     int        `json:"-"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	TokenHash  string     `json:"-"`
+	Scopes     []string   `json:"scopes" gorm:"serializer:json"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	RevokedAt  *time.Time `json:"-"`
+}
+
+type CreateAccessToken struct {
+	Name      string     `json:"name"`
+	Scopes    []string   `json:"scopes"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+type RecoveryCode struct {
+	ID       int
+	UserId   int
+	CodeHash string
+	UsedAt   *time.Time
+}
+
+// MFAEnrollment is shown once when a user sets up two-factor authentication.
+type MFAEnrollment struct {
+	Secret        string   `json:"secret"`
+	URI           string   `json:"otpauth_uri"`
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+type MFARequest struct {
+	MFAToken string `json:"mfa_token"`
+	Code     string `json:"code"`
+}
+
+// Guest is an identity for playing without an account. Its history moves
+// to the account it is merged into.
+type Guest struct {
+	ID         string
+	CreatedAt  time.Time
+	MergedInto *int
+	MergedAt   *time.Time
+}
@@ -0,0 +1,39 @@
+package models
+
+import "time"
+
+type LeaderboardPeriod string
+
+const (
+	LeaderboardAllTime LeaderboardPeriod = "all"
+	LeaderboardWeekly  LeaderboardPeriod = "week"
+	LeaderboardMonthly LeaderboardPeriod = "month"
+)
+
+// LeaderboardQuery selects a view of a script leaderboard. An empty
+// ScriptHash covers every version of the script.
+type LeaderboardQuery struct {
+	Period     LeaderboardPeriod
+	ScriptHash string
+	Limit      int
+	Offset     int
+}
+
+// LeaderboardRow is the best score of a player; Parties counts every party
+// of theirs within the view.
+type LeaderboardRow struct {
+	Rank        int       `json:"rank"`
+	UserId      int       `json:"user_id"`
+	DisplayName string    `json:"display_name"`
+	Score       int       `json:"score"`
+	Parties     int       `json:"parties"`
+	PartyId     int       `json:"party_id"`
+	AchievedAt  time.Time `json:"achieved_at"`
+}
+
+type Leaderboard struct {
+	Period     LeaderboardPeriod `json:"period"`
+	ScriptHash string            `json:"script_hash,omitempty"`
+	Total      int               `json:"total"`
+	Rows       []LeaderboardRow  `json:"rows"`
+}
@@ -0,0 +1,112 @@
+package models
+
+import "time"
+
+type PartyStatus string
+
+const (
+	PartyActive    PartyStatus = "active"
+	PartyFinished  PartyStatus = "finished"
+	PartyCancelled PartyStatus = "cancelled"
+)
+
+// Party is a single run of a script in a room. ScriptHash pins the exact
+// version that was played, ScenarioId ties it to the script across updates.
+type Party struct {
+	ID           int                `json:"id"`
+	RoomCode     string             `json:"room_code"`
+	ScenarioId   *int               `json:"scenario_id"`
+	ScriptHash   string             `json:"script_hash"`
+	HostId       *int               `json:"host_id"`
+	Status       PartyStatus        `json:"status"`
+	StartedAt    time.Time          `json:"started_at"`
+	EndedAt      *time.Time         `json:"ended_at"`
+	Participants []PartyParticipant `json:"participants,omitempty" gorm:"foreignKey:PartyId"`
+}
+
+// PartyParticipant is a player of a party. UserId is nil for guests, who
+// may have a GuestId instead; SessionId is the connection identity they
+// played under.
+type PartyParticipant struct {
+	ID        int     `json:"id"`
+	PartyId   int     `json:"party_id"`
+	UserId    *int    `json:"user_id"`
+	GuestId   *string `json:"-"`
+	SessionId string  `json:"-"`
+	Nickname  string  `json:"nickname"`
+	Wins      int     `json:"wins"`
+	Rank      int     `json:"rank"`
+}
+
+// PartyEvent is an entry of a room's event log. Events of a lobby, before
+// any party started, have no PartyId.
+type PartyEvent struct {
+	ID         int            `json:"-"`
+	RoomCode   string         `json:"room_code"`
+	PartyId    *int           `json:"party_id"`
+	Seq        int            `json:"seq"`
+	Type       string         `json:"type"`
+	SessionId  string         `json:"session_id,omitempty"`
+	Data       map[string]any `json:"data" gorm:"serializer:json"`
+	OccurredAt time.Time      `json:"occurred_at"`
+}
+
+// PartyAnswer is what a participant answered to a single step of a party.
+// Participants that did not answer still get one, with Answered unset.
+type PartyAnswer struct {
+	ID         int     `json:"-"`
+	PartyId    int     `json:"party_id"`
+	SessionId  string  `json:"-"`
+	UserId     *int    `json:"user_id"`
+	GuestId    *string `json:"-"`
+	Nickname   string  `json:"nickname"`
+	Step       int     `json:"step"`
+	Query      string  `json:"query"`
+	Answer     string  `json:"answer"`
+	Answered   bool    `json:"answered"`
+	Correct    bool    `json:"correct"`
+	Points     int     `json:"points"`
+	ResponseMs *int64  `json:"response_ms"`
+}
+
+// PartyResult is a row of a party results export.
+type PartyResult struct {
+	Nickname   string `json:"nickname"`
+	Step       int    `json:"step"`
+	Query      string `json:"query"`
+	Answer     string `json:"answer"`
+	Correct    bool   `json:"correct"`
+	Points     int    `json:"points"`
+	ResponseMs *int64 `json:"response_ms"`
+	Rank       int    `json:"rank"`
+}
+
+// ScriptAnalytics aggregates every party of a script within a date range.
+// Percentages are in the 0-100 range.
+type ScriptAnalytics struct {
+	Plays             int              `json:"plays"`
+	Finished          int              `json:"finished"`
+	CompletionPercent float64          `json:"completion_percent"`
+	AveragePlayers    float64          `json:"average_players"`
+	Queries           []QueryAnalytics `json:"queries" gorm:"-"`
+}
+
+// QueryAnalytics aggregates the answers to a single query. DropOffPercent is
+// the share of players who started a party but were gone by this query.
+type QueryAnalytics struct {
+	Query            string        `json:"query"`
+	Parties          int           `json:"parties"`
+	Players          int           `json:"players"`
+	Answers          int           `json:"answers"`
+	Correct          int           `json:"correct"`
+	PercentCorrect   float64       `json:"percent_correct"`
+	MedianResponseMs *float64      `json:"median_response_ms"`
+	DropOffPercent   float64       `json:"drop_off_percent"`
+	Distribution     []AnswerCount `json:"answer_distribution" gorm:"-"`
+}
+
+type AnswerCount struct {
+	Query  string `json:"-"`
+	Answer string `json:"answer"`
+	Count  int    `json:"count"`
+}
@@ -22,18 +22,29 @@ func (Script) TableName() string {
 }
 
 type CreateScript struct {
-	ScriptFile  io.Reader `json:"script_file"`
-	CoverFile   io.Reader `json:"cover_file"`
-	Title       string    `json:"title"`
-	Description string    `json:"description"`
-	Public      bool      `json:"public"`
-	CreatorId   int       `json:"creator_id"`
+	ScriptFile   io.Reader `json:"script_file"`
+	CoverFile    io.Reader `json:"cover_file"`
+	Title        string    `json:"title"`
+	Description  string    `json:"description"`
+	Public       bool      `json:"public"`
+	CreatorId    int       `json:"creator_id"`
+	RequireTests bool      `json:"require_tests"`
 }
 
 type UpdateScript struct {
-	ScriptFile  io.Reader `json:"script_file"`
-	CoverFile   io.Reader `json:"cover_file"`
-	Title       string    `json:"title"`
-	Description string    `json:"description"`
-	Public      bool      `json:"public"`
+	ScriptFile   io.Reader `json:"script_file"`
+	CoverFile    io.Reader `json:"cover_file"`
+	Title        string    `json:"title"`
+	Description  string    `json:"description"`
+	Public       bool      `json:"public"`
+	RequireTests bool      `json:"require_tests"`
+}
+
+type SimulateScript struct {
+	Bots       int      `json:"bots"`
+	Strategy   string   `json:"strategy"`
+	Strategies []string `json:"strategies"`
+	Seed       uint64   `json:"seed"`
+	MaxSteps   int      `json:"max_steps"`
+	Language   string   `json:"language"`
 }
@@ -1,13 +1,69 @@
 package models
 
-import "time"
+import (
+	"io"
+	"time"
+)
+
+type Role string
+
+const (
+	RoleUser      Role = "user"
+	RoleModerator Role = "moderator"
+	RoleAdmin     Role = "admin"
+)
+
+func (r Role) Valid() bool {
+	return r == RoleUser || r == RoleModerator || r == RoleAdmin
+}
 
 type User struct {
 	ID           int       `json:"id"`
 	Email        string    `json:"email"`
 	PasswordHash string    `json:"password_hash"`
+	DisplayName  string    `json:"display_name"`
+	AvatarHash   string    `json:"avatar_hash"`
+	Language     string    `json:"language"`
 	CreatedAt    time.Time `json:"created_at"`
 	UpdatedAt    time.Time `json:"updated_at"`
+
+	EmailVerifiedAt *time.Time `json:"email_verified_at"`
+	Role            Role       `json:"role" gorm:"default:user"`
+	BannedAt        *time.Time `json:"banned_at"`
+	BanReason       string     `json:"ban_reason"`
+
+	TOTPSecret    string     `json:"-" gorm:"column:totp_secret"`
+	TOTPEnabledAt *time.Time `json:"-" gorm:"column:totp_enabled_at"`
+	TOTPLastStep  int64      `json:"-" gorm:"column:totp_last_step"`
+}
+
+// AdminUser is a user as shown to moderators and admins.
+type AdminUser struct {
+	ID            int        `json:"id"`
+	Email         string     `json:"email"`
+	DisplayName   string     `json:"display_name"`
+	Role          Role       `json:"role"`
+	EmailVerified bool       `json:"email_verified"`
+	BannedAt      *time.Time `json:"banned_at,omitempty"`
+	BanReason     string     `json:"ban_reason,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+type BanRequest struct {
+	Reason string `json:"reason"`
+}
+
+type RoleRequest struct {
+	Role Role `json:"role"`
+}
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email"`
+}
+
+type ResetPasswordRequest struct {
+	Token    string `json:"token"`
+	Password string `json:"password"`
 }
 
 type LoginRequest struct {
@@ -19,3 +75,77 @@ type RegisterRequest struct {
 	Email    string `json:"email"`
 	Password string `json:"password"`
 }
+
+// Profile is what others can see of a user; Email, EmailVerified,
+// MFAEnabled and Language are only filled in for the user themselves.
+type Profile struct {
+	ID            int          `json:"id"`
+	Email         string       `json:"email,omitempty"`
+	EmailVerified bool         `json:"email_verified,omitempty"`
+	MFAEnabled    bool         `json:"mfa_enabled,omitempty"`
+	DisplayName   string       `json:"display_name"`
+	AvatarHash    string       `json:"avatar_hash"`
+	Language      string       `json:"language,omitempty"`
+	CreatedAt     time.Time    `json:"created_at"`
+	Stats         ProfileStats `json:"stats"`
+}
+
+type ProfileStats struct {
+	GamesPlayed     int           `json:"games_played"`
+	GamesWon        int           `json:"games_won"`
+	GamesHosted     int           `json:"games_hosted"`
+	FavoriteScripts []ScriptStats `json:"favorite_scripts" gorm:"-"`
+	BestScores      []ScriptStats `json:"best_scores" gorm:"-"`
+}
+
+// ScriptStats is how a user did at a script: how many parties they played
+// or their best number of wins in a single party.
+type ScriptStats struct {
+	ScenarioId int    `json:"scenario_id"`
+	Title      string `json:"title"`
+	ScriptHash string `json:"script_hash"`
+	Plays      int    `json:"plays,omitempty"`
+	Wins       int    `json:"wins,omitempty"`
+}
+
+type UpdateProfile struct {
+	DisplayName *string   `json:"display_name" form:"display_name"`
+	Language    *string   `json:"language" form:"language"`
+	AvatarFile  io.Reader `json:"-" form:"-"`
+}
+
+type UserAchievement struct {
+	UserId      int       `json:"-"`
+	Achievement string    `json:"id"`
+	Title       string    `json:"title" gorm:"-"`
+	Description string    `json:"description" gorm:"-"`
+	PartyId     *int      `json:"party_id"`
+	EarnedAt    time.Time `json:"earned_at"`
+}
+
+// AccountExport is everything kept about a user, as handed out to them.
+// Parties only list the user's own participation.
+type AccountExport struct {
+	ExportedAt time.Time     `json:"exported_at"`
+	Profile    *Profile      `json:"profile"`
+	Scripts    []*Script     `json:"scripts"`
+	Parties    []*Party      `json:"parties"`
+	Answers    []PartyAnswer `json:"answers"`
+}
+
+// ScriptsPolicy is what happens to the scripts of a deleted account.
+type ScriptsPolicy string
+
+const (
+	ScriptsDelete   ScriptsPolicy = "delete"
+	ScriptsTransfer ScriptsPolicy = "transfer"
+)
+
+// DeleteAccountRequest confirms the deletion of an account with its password,
+// or with an MFA code when it has none.
+type DeleteAccountRequest struct {
+	Password   string        `json:"password"`
+	Code       string        `json:"code"`
+	Scripts    ScriptsPolicy `json:"scripts"`
+	TransferTo string        `json:"transfer_to"`
+}
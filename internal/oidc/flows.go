@@ -0,0 +1,83 @@
+package oidc
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"sync"
+	"time"
+)
+
+// FlowTTL is how long a started login can take to come back.
+const FlowTTL = 10 * time.Minute
+
+// Flow is a login that was started but has not come back from the provider
+// yet.
+type Flow struct {
+	Provider string
+	Nonce    string
+	Verifier string
+	Expires  time.Time
+}
+
+// Flows remembers started logins by their state parameter. Each state can
+// only be taken once.
+type Flows struct {
+	mutex sync.Mutex
+	flows map[string]Flow
+}
+
+func NewFlows() *Flows {
+	return &Flows{flows: make(map[string]Flow)}
+}
+
+// Start returns the state for a new login along with its flow.
+func (f *Flows) Start(provider string) (string, Flow, error) {
+	state, err := randomString()
+	if err != nil {
+		return "", Flow{}, err
+	}
+	nonce, err := randomString()
+	if err != nil {
+		return "", Flow{}, err
+	}
+	verifier, err := randomString()
+	if err != nil {
+		return "", Flow{}, err
+	}
+
+	flow := Flow{Provider: provider, Nonce: nonce, Verifier: verifier, Expires: time.Now().Add(FlowTTL)}
+
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	now := time.Now()
+	for state, pending := range f.flows {
+		if now.After(pending.Expires) {
+			delete(f.flows, state)
+		}
+	}
+	f.flows[state] = flow
+
+	return state, flow, nil
+}
+
+func (f *Flows) Take(state string) (Flow, bool) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	flow, ok := f.flows[state]
+	delete(f.flows, state)
+
+	if !ok || time.Now().After(flow.Expires) {
+		return Flow{}, false
+	}
+	return flow, true
+}
+
+func randomString() (string, error) {
+	bytes := make([]byte, 32)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(bytes), nil
+}
@@ -0,0 +1,117 @@
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	go_jwt "github.com/golang-jwt/jwt/v5"
+)
+
+// mockIssuer is a minimal OIDC provider that issues an ID token for the
+// authorization code "code" once the PKCE verifier checks out.
+func mockIssuer(t *testing.T, claims go_jwt.MapClaims) (*httptest.Server, *string) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var challenge string
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 server.URL,
+			"authorization_endpoint": server.URL + "/authorize",
+			"token_endpoint":         server.URL + "/token",
+			"jwks_uri":               server.URL + "/jwks",
+		})
+	})
+
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]any{"keys": []map[string]string{{
+			"kid": "test",
+			"kty": "RSA",
+			"use": "sig",
+			"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	})
+
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		verifier := sha256.Sum256([]byte(r.PostFormValue("code_verifier")))
+		if r.PostFormValue("code") != "code" || base64.RawURLEncoding.EncodeToString(verifier[:]) != challenge {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		claims["iss"] = server.URL
+		token := go_jwt.NewWithClaims(go_jwt.SigningMethodRS256, claims)
+		token.Header["kid"] = "test"
+		idToken, err := token.SignedString(key)
+		if err != nil {
+			t.Error(err)
+		}
+
+		json.NewEncoder(w).Encode(map[string]string{"id_token": idToken})
+	})
+
+	return server, &challenge
+}
+
+func TestProviderExchange(t *testing.T) {
+	server, challenge := mockIssuer(t, go_jwt.MapClaims{
+		"sub":            "42",
+		"aud":            "client",
+		"exp":            time.Now().Add(time.Minute).Unix(),
+		"nonce":          "nonce",
+		"email":          "player@example.com",
+		"email_verified": "true",
+	})
+
+	provider := NewProvider(Config{Name: "mock", Issuer: server.URL, ClientID: "client", RedirectURL: "http://localhost/callback"})
+	ctx := context.Background()
+
+	authURL, err := provider.AuthURL(ctx, "state", "nonce", "verifier")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parsed, err := url.Parse(authURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if method := parsed.Query().Get("code_challenge_method"); method != "S256" {
+		t.Fatalf("code_challenge_method = %q", method)
+	}
+	*challenge = parsed.Query().Get("code_challenge")
+
+	if _, err := provider.Exchange(ctx, "code", "wrong verifier", "nonce"); err == nil {
+		t.Fatal("exchange with a wrong verifier succeeded")
+	}
+
+	if _, err := provider.Exchange(ctx, "code", "verifier", "other nonce"); err != ErrInvalidIDToken {
+		t.Fatalf("exchange with a wrong nonce: %v", err)
+	}
+
+	claims, err := provider.Exchange(ctx, "code", "verifier", "nonce")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := Claims{Subject: "42", Email: "player@example.com", EmailVerified: true}
+	if *claims != want {
+		t.Fatalf("claims = %+v, want %+v", *claims, want)
+	}
+}
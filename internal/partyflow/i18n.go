@@ -0,0 +1,168 @@
+package partyflow
+
+import (
+	"fmt"
+	"slices"
+)
+
+// Once a WebPartySpec declares languages = ["ru", "en"], any table whose keys
+// are all declared languages is a localized value, e.g.
+// title = { ru = "Угадай число", en = "Guess the number" }. The first
+// language is the default one.
+
+var unlocalizableKeys = []string{"type"}
+
+func (partyFlow *PartyFlow) GetLanguages() []string {
+	return partyFlow.languages
+}
+
+func (partyFlow *PartyFlow) HasLanguage(language string) bool {
+	return slices.Contains(partyFlow.languages, language)
+}
+
+func (partyFlow *PartyFlow) GetMissingTranslations() []string {
+	return partyFlow.missingTranslations
+}
+
+// Localize returns a copy of a query section with every localized value
+// resolved for language, falling back to the default language.
+func (partyFlow *PartyFlow) Localize(section map[string]any, language string) map[string]any {
+	if section == nil {
+		return nil
+	}
+
+	return partyFlow.localize(section, language).(map[string]any)
+}
+
+// Translations lists every variant of a possibly localized value, default
+// language first.
+func (partyFlow *PartyFlow) Translations(value any) []any {
+	localized, ok := value.(map[string]any)
+	if !ok || !partyFlow.isLocalized(localized) {
+		return []any{value}
+	}
+
+	translations := []any{}
+	for _, language := range partyFlow.languages {
+		if translation, ok := localized[language]; ok {
+			translations = append(translations, translation)
+		}
+	}
+
+	return translations
+}
+
+func (partyFlow *PartyFlow) localize(value any, language string) any {
+	switch value := value.(type) {
+	case map[string]any:
+		if partyFlow.isLocalized(value) {
+			translation, ok := value[language]
+			if !ok {
+				translation = partyFlow.Translations(value)[0]
+			}
+			return partyFlow.localize(translation, language)
+		}
+
+		localized := make(map[string]any, len(value))
+		for k, v := range value {
+			localized[k] = partyFlow.localize(v, language)
+		}
+		return localized
+
+	case []any:
+		localized := make([]any, len(value))
+		for i, v := range value {
+			localized[i] = partyFlow.localize(v, language)
+		}
+		return localized
+	}
+
+	return value
+}
+
+func (partyFlow *PartyFlow) isLocalized(value map[string]any) bool {
+	if len(partyFlow.languages) == 0 || len(value) == 0 {
+		return false
+	}
+
+	for key := range value {
+		if !partyFlow.HasLanguage(key) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func parseLanguages(value any) ([]string, error) {
+	languages := []string{}
+
+	for _, language := range anySlice(value) {
+		if slices.Contains(languages, language.(string)) {
+			return nil, fmt.Errorf("Language <%s> declared twice.", language)
+		}
+		languages = append(languages, language.(string))
+	}
+
+	if len(languages) == 0 {
+		return nil, fmt.Errorf("At least one language should be declared in 'languages'.")
+	}
+
+	return languages, nil
+}
+
+// checkTranslations reports every localized value of a query that lacks
+// some of the declared languages, and fails on localized structural keys.
+func (partyFlow *PartyFlow) checkTranslations(query *PartyQuery) error {
+	sections := map[string]map[string]any{
+		"layout":     query.Layout,
+		"input":      query.Input,
+		"overviewer": query.Overviewer,
+		"vote":       query.Vote,
+	}
+
+	for _, sectionName := range []string{"layout", "input", "overviewer", "vote"} {
+		section := sections[sectionName]
+
+		for _, key := range unlocalizableKeys {
+			value, ok := section[key].(map[string]any)
+			if ok && partyFlow.isLocalized(value) {
+				return fmt.Errorf("Key '%s' can not be localized (%s.%s).", key, query.Name, sectionName)
+			}
+		}
+
+		partyFlow.collectMissingTranslations(fmt.Sprintf("%s.%s", query.Name, sectionName), section)
+	}
+
+	return nil
+}
+
+func (partyFlow *PartyFlow) collectMissingTranslations(path string, value any) {
+	switch value := value.(type) {
+	case map[string]any:
+		if partyFlow.isLocalized(value) {
+			for _, language := range partyFlow.languages {
+				if _, ok := value[language]; !ok {
+					partyFlow.missingTranslations = append(partyFlow.missingTranslations,
+						fmt.Sprintf("Missing <%s> translation of %s.", language, path))
+				}
+			}
+			return
+		}
+
+		keys := make([]string, 0, len(value))
+		for key := range value {
+			keys = append(keys, key)
+		}
+		slices.Sort(keys)
+
+		for _, key := range keys {
+			partyFlow.collectMissingTranslations(path+"."+key, value[key])
+		}
+
+	case []any:
+		for i, v := range value {
+			partyFlow.collectMissingTranslations(fmt.Sprintf("%s[%d]", path, i), v)
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package partyflow
+
+import (
+	"io"
+	"testing"
+)
+
+const localized = `
+start = "intro"
+languages = ["ru", "en"]
+
+[intro]
+    [intro.layout]
+    type = "basic"
+    title = { ru = "Угадай число", en = "Guess the number" }
+    description = { ru = "Игра скоро начнётся" }
+    items = [{ ru = "один", en = "one" }, "2"]
+
+        [intro.to.end]
+        timer = 3
+`
+
+func TestLocalize(t *testing.T) {
+	partyFlow, err := New().FromString("localized", localized, io.Discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	layout := partyFlow.Localize(partyFlow.start.Layout, "en")
+	if layout["title"] != "Guess the number" || layout["type"] != "basic" {
+		t.Fatalf("unexpected english layout %v", layout)
+	}
+
+	if layout["description"] != "Игра скоро начнётся" {
+		t.Fatalf("missing translation should fall back to the default language, got %v", layout["description"])
+	}
+
+	if items := layout["items"].([]any); items[0] != "one" || items[1] != "2" {
+		t.Fatalf("unexpected items %v", items)
+	}
+
+	missing := partyFlow.GetMissingTranslations()
+	if len(missing) != 1 || missing[0] != "Missing <en> translation of intro.layout.description." {
+		t.Fatalf("unexpected missing translations %v", missing)
+	}
+}
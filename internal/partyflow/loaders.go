@@ -7,9 +7,11 @@ import (
 	"log"
 	"os"
 	"reflect"
+	"slices"
 	"strings"
 
 	"github.com/BurntSushi/toml"
+	"github.com/theWebPartyTime/server/internal/colors"
 )
 
 func (partyFlow *PartyFlow) FromFile(filePath string, logWriter io.Writer) (*PartyFlow, error) {
@@ -46,15 +48,20 @@ func (partyFlow *PartyFlow) FromString(debugName string, webPartySpec string, lo
 	}
 
 	partyFlow.start = start
+
+	slices.Sort(partyFlow.missingTranslations)
+	for _, missing := range partyFlow.missingTranslations {
+		partyFlow.logger.Printf("%v %s", colors.Warning("Warning:"), missing)
+	}
+
 	partyFlow.logger.Printf("Ready to start")
 
 	return partyFlow, generalError
 }
 
-func (partyFlow *PartyFlow) parse(webPartySpec map[string]any) (*PartyQuery, error) {
-	var start *PartyQuery = nil
-	var parseError error = nil
-
+// parse returns its results by name so that a failed type cast, caught by
+// recover, comes back as an error rather than as nothing at all.
+func (partyFlow *PartyFlow) parse(webPartySpec map[string]any) (start *PartyQuery, parseError error) {
 	defer func() {
 		if r := recover(); r != nil {
 			start = nil
@@ -65,7 +72,21 @@ func (partyFlow *PartyFlow) parse(webPartySpec map[string]any) (*PartyQuery, err
 	startQueryName := webPartySpec["start"].(string)
 	var nameToQuery = map[string]*PartyQuery{"end": {Name: "end"}}
 
-	ignoreKeys := map[string]any{"start": nil, "end": nil}
+	ignoreKeys := map[string]any{"start": nil, "end": nil, "tests": nil, "languages": nil, "variables": nil,
+		"rounds": nil, "round_summary": nil}
+
+	partyFlow.variables = mapOrNil(webPartySpec["variables"])
+
+	partyFlow.languages = nil
+	partyFlow.missingTranslations = nil
+
+	if languages, ok := webPartySpec["languages"]; ok {
+		partyFlow.languages, parseError = parseLanguages(languages)
+		if parseError != nil {
+			return nil, parseError
+		}
+	}
+
 	for key, value := range webPartySpec {
 		_, ignoreKey := ignoreKeys[key]
 
@@ -150,6 +171,10 @@ func (partyFlow *PartyFlow) parse(webPartySpec map[string]any) (*PartyQuery, err
 			query.Vote = voteSection
 		}
 
+		if err := partyFlow.checkTranslations(&query); err != nil {
+			return nil, err
+		}
+
 		nameToQuery[query.Name] = &query
 
 		if query.Name == startQueryName {
@@ -157,6 +182,14 @@ func (partyFlow *PartyFlow) parse(webPartySpec map[string]any) (*PartyQuery, err
 		}
 	}
 
+	partyFlow.rounds = nil
+	if rounds, ok := webPartySpec["rounds"]; ok {
+		partyFlow.rounds, parseError = parseRounds(rounds, webPartySpec["round_summary"], nameToQuery)
+		if parseError != nil {
+			return nil, parseError
+		}
+	}
+
 	for queryName := range webPartySpec {
 		_, ignore := ignoreKeys[queryName]
 		if ignore {
@@ -166,12 +199,22 @@ func (partyFlow *PartyFlow) parse(webPartySpec map[string]any) (*PartyQuery, err
 		query := nameToQuery[queryName]
 		queryData := webPartySpec[queryName].(map[string]any)
 
+		if err := partyFlow.checkTemplates(query, nameToQuery); err != nil {
+			return nil, err
+		}
+
 		destinations := mapOrNil(queryData["to"])
 		if destinations == nil {
 			return nil, fmt.Errorf("Query without destination (%s)", queryName)
 		}
 
+		destinationNames := make([]string, 0, len(destinations))
 		for destination := range destinations {
+			destinationNames = append(destinationNames, destination)
+		}
+		slices.Sort(destinationNames)
+
+		for _, destination := range destinationNames {
 			_, destinationFound := nameToQuery[destination]
 			if !destinationFound {
 				return nil, fmt.Errorf("PartyQuery <%s> referenced in <%s> not found.", destination, queryName)
@@ -189,6 +232,14 @@ func (partyFlow *PartyFlow) parse(webPartySpec map[string]any) (*PartyQuery, err
 		}
 	}
 
+	tests, ok := webPartySpec["tests"]
+	if ok {
+		partyFlow.tests, parseError = partyFlow.parseTests(tests, nameToQuery)
+		if parseError != nil {
+			return nil, parseError
+		}
+	}
+
 	return start, parseError
 }
 
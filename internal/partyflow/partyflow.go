@@ -5,6 +5,8 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"maps"
+	"slices"
 	"sync/atomic"
 	"time"
 
@@ -17,11 +19,38 @@ type Standing struct {
 	WinCount  int    `json:"winCount"`
 }
 
+// Clock lets a PartyFlow run against something other than wall time.
+// Settle is called whenever the flow has registered everything it is about
+// to wait on; a virtual clock uses it to jump to the earliest deadline.
+type Clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+	Settle()
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time                         { return time.Now() }
+func (realClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+func (realClock) Settle()                                {}
+
 type PartyFlow struct {
 	logger *log.Logger
+	clock  Clock
 
 	start   *PartyQuery
 	current *PartyQuery
+	tests   []TestCase
+
+	languages           []string
+	missingTranslations []string
+	variables           map[string]any
+	answers             map[string]map[string]string
+
+	rounds          []*Round
+	round           *Round
+	roundStandings  map[string]Standing
+	onRoundFinished func(*Round, map[string]Standing)
 
 	inputCheckers     map[string]input.Checker
 	conditionCheckers map[string]func(any, map[string]any) <-chan struct{}
@@ -30,6 +59,7 @@ type PartyFlow struct {
 	onQuery      func(*PartyQuery)
 	onMove       func()
 	onFinished   func()
+	onPanic      func(any)
 	onGetInputs  func(*PartyQuery) map[string]string
 	onGetWinners func(*PartyQuery) []string
 	standings    map[string]Standing
@@ -54,6 +84,9 @@ type PartyQuery struct {
 	Layout       map[string]any
 	NextVariants []conditionalMove
 	Step         int
+	Round        *Round
+
+	summary bool
 }
 
 func New() *PartyFlow {
@@ -70,8 +103,11 @@ func New() *PartyFlow {
 		stop:              nil,
 		onMove:            func() {},
 		onFinished:        func() {},
+		onPanic:           func(any) {},
+		onRoundFinished:   func(*Round, map[string]Standing) {},
 		onGetInputs:       func(*PartyQuery) map[string]string { return map[string]string{} },
 		logger:            nil,
+		clock:             realClock{},
 	}
 
 	return &partyFlow
@@ -97,6 +133,7 @@ func (partyFlow *PartyFlow) Start() {
 			partyFlow.logger.Printf("PartyFlow execution panicked, unloading from memory...")
 			partyFlow.start = nil
 			partyFlow.current = nil
+			partyFlow.onPanic(r)
 		}
 
 		partyFlow.logger.Printf("PartyFlow finished.")
@@ -109,6 +146,9 @@ func (partyFlow *PartyFlow) Start() {
 
 	partyFlow.current = partyFlow.start
 	partyFlow.standings = make(map[string]Standing)
+	partyFlow.answers = make(map[string]map[string]string)
+	partyFlow.round = nil
+	partyFlow.roundStandings = make(map[string]Standing)
 
 	partyFlow.logger.Printf("Starting from <%s>", partyFlow.current.Name)
 
@@ -117,12 +157,13 @@ func (partyFlow *PartyFlow) Start() {
 	partyFlowCancelled := false
 
 	initialWait := 1
-	<-time.After(time.Duration(initialWait) * time.Second)
+	partyFlow.sleep(time.Duration(initialWait) * time.Second)
 
 	for {
 		partyFlow.stepCounter.Add(1)
 
 		partyFlow.current.Step = int(partyFlow.stepCounter.Load())
+		partyFlow.enterRound()
 		partyFlow.logger.Printf("%d | Waiting on <%s>", partyFlow.current.Step, partyFlow.current.Name)
 
 		partyFlow.onQuery(partyFlow.current)
@@ -131,29 +172,43 @@ func (partyFlow *PartyFlow) Start() {
 		ctx, cancelOtherConditions := context.WithCancel(context.Background())
 
 		for moveToVariant, conditionalMove := range partyFlow.current.NextVariants {
+			conditionNames := make([]string, 0, len(conditionalMove.when))
 			for condition := range conditionalMove.when {
-				_, ok := partyFlow.conditionCheckers[condition]
+				conditionNames = append(conditionNames, condition)
+			}
+			slices.Sort(conditionNames)
+
+			for _, condition := range conditionNames {
+				checker, ok := partyFlow.conditionCheckers[condition]
 
 				if !ok {
 					log.Panicf("%v <%s> %v",
 						colors.Error("Condition function"), condition, colors.Error("is not found"))
 				}
 
+				met := checker(conditionalMove.when[condition], partyFlow.conditionArgs[condition])
+
 				go func(ctx context.Context) {
+					move := moveToVariant
+
 					select {
 					case <-ctx.Done():
+						return
 					case <-partyFlow.context.Done():
 						partyFlowCancelled = true
-						moveTo <- -1
-					case <-partyFlow.conditionCheckers[condition](
-						conditionalMove.when[condition],
-						partyFlow.conditionArgs[condition]):
-						moveTo <- moveToVariant
+						move = -1
+					case <-met:
+					}
+
+					select {
+					case moveTo <- move:
+					case <-ctx.Done():
 					}
 				}(ctx)
 			}
 		}
 
+		partyFlow.clock.Settle()
 		path = <-moveTo
 		cancelOtherConditions()
 
@@ -170,9 +225,10 @@ func (partyFlow *PartyFlow) Start() {
 		if partyFlow.skipGetWinners {
 			partyFlow.skipGetWinners = false
 		} else {
-			<-time.After(200 * time.Millisecond)
+			partyFlow.sleep(200 * time.Millisecond)
 			winners := partyFlow.onGetWinners(partyFlow.current)
 			inputs := partyFlow.onGetInputs(partyFlow.current)
+			partyFlow.answers[partyFlow.current.Name] = inputs
 
 			for _, winner := range winners {
 				_, ok := partyFlow.standings[winner]
@@ -184,18 +240,17 @@ func (partyFlow *PartyFlow) Start() {
 
 				partyFlow.standings[winner] = Standing{
 					WinCount: partyFlow.standings[winner].WinCount + 1, LastInput: inputs[winner]}
+
+				if partyFlow.round != nil {
+					partyFlow.roundStandings[winner] = Standing{
+						WinCount: partyFlow.roundStandings[winner].WinCount + 1, LastInput: inputs[winner]}
+				}
 			}
 
 			partyFlow.logger.Printf("Winners -> %v", partyFlow.standings)
 		}
 
-		correct, hasCorrect := partyFlow.current.Input["correct"]
-		votingQueried := false
-
-		if hasCorrect {
-			correct = correct.(string)
-			votingQueried = correct == "vote"
-		}
+		votingQueried := partyFlow.current.Input["correct"] == "vote"
 
 		overviewerQueried := partyFlow.current.Overviewer != nil
 		next, err := partyFlow.next(path)
@@ -220,6 +275,7 @@ func (partyFlow *PartyFlow) Start() {
 					"candidates": partyFlow.onGetInputs(partyFlow.current)},
 				Overviewer:   partyFlow.current.Overviewer,
 				NextVariants: []conditionalMove{{to: next, when: moveWhen}},
+				Round:        partyFlow.current.Round,
 			}
 		} else if overviewerQueried {
 			moveWhen := make(map[string]any)
@@ -233,10 +289,11 @@ func (partyFlow *PartyFlow) Start() {
 			nextQuery = &PartyQuery{
 				Name: fmt.Sprintf("%s (overviewer)", partyFlow.current.Name),
 				Layout: map[string]any{"type": "overviewer " + partyFlow.current.Overviewer["type"].(string),
-					"winners": partyFlow.standings},
+					"winners": maps.Clone(partyFlow.standings)},
 				Input:        nil,
 				Overviewer:   nil,
 				NextVariants: []conditionalMove{{to: next, when: moveWhen}},
+				Round:        partyFlow.current.Round,
 			}
 
 			partyFlow.skipGetWinners = true
@@ -246,8 +303,6 @@ func (partyFlow *PartyFlow) Start() {
 			if next == nil {
 				partyFlow.logger.Panicf("%v <%v>.", colors.Error(
 					"End reached unexpectedly on query "), partyFlow.current.Name)
-			} else if partyFlow.current.NextVariants[0].to.Name == "end" {
-				break
 			}
 
 			partyFlow.logger.Printf("<%s> --> <%s>",
@@ -255,6 +310,11 @@ func (partyFlow *PartyFlow) Start() {
 			nextQuery, _ = partyFlow.next(path)
 		}
 
+		nextQuery = partyFlow.leaveRound(nextQuery)
+		if nextQuery.Name == "end" {
+			break
+		}
+
 		partyFlow.current = nextQuery
 		partyFlow.onMove()
 	}
@@ -269,6 +329,12 @@ func (partyFlow *PartyFlow) Stop() error {
 	return nil
 }
 
+func (partyFlow *PartyFlow) sleep(d time.Duration) {
+	wake := partyFlow.clock.After(d)
+	partyFlow.clock.Settle()
+	<-wake
+}
+
 func (partyQuery *PartyQuery) setMoveToNilIfNoVariants() {
 	if partyQuery.NextVariants == nil {
 		partyQuery.NextVariants = []conditionalMove{{
@@ -282,6 +348,31 @@ func (partyFlow *PartyFlow) GetStep() int {
 	return int(partyFlow.stepCounter.Load())
 }
 
+// PlayerInput returns the input section as it is sent to players: without
+// the correct answer and tagged with the current step.
+func (partyQuery *PartyQuery) PlayerInput() map[string]any {
+	if partyQuery.Input == nil {
+		return nil
+	}
+
+	input := make(map[string]any)
+	for k, v := range partyQuery.Input {
+		input[k] = v
+	}
+
+	delete(input, "correct")
+	input["step"] = partyQuery.Step
+	return input
+}
+
+func (partyFlow *PartyFlow) GetStandings() map[string]Standing {
+	return partyFlow.standings
+}
+
+func (partyFlow *PartyFlow) SetClock(clock Clock) {
+	partyFlow.clock = clock
+}
+
 func (partyFlow *PartyFlow) OnGetWinners(cb func(*PartyQuery) []string) {
 	partyFlow.onGetWinners = cb
 }
@@ -302,6 +393,11 @@ func (partyFLow *PartyFlow) OnFinished(cb func()) {
 	partyFLow.onFinished = cb
 }
 
+// OnPanic receives whatever Start recovered from before OnFinished runs.
+func (partyFlow *PartyFlow) OnPanic(cb func(any)) {
+	partyFlow.onPanic = cb
+}
+
 func (partyFlow *PartyFlow) AddInputChecker(name string, checker input.Checker) {
 	partyFlow.inputCheckers[name] = checker
 }
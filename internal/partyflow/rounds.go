@@ -0,0 +1,190 @@
+package partyflow
+
+import (
+	"fmt"
+	"maps"
+)
+
+// Round groups queries of a WebPartySpec declared in [[rounds]]:
+//
+//	[[rounds]]
+//	name = "Warm-up"
+//	queries = ["guess1", "guess2"]
+//	summary = { type = "round", timer = 5 }
+//
+// Whenever the flow leaves a round, its summary is played as an overviewer
+// with the round standings. The summary defaults to [round_summary] and can
+// be turned off with summary = false.
+type Round struct {
+	Index int    `json:"index"`
+	Total int    `json:"total"`
+	Name  string `json:"name"`
+
+	Summary map[string]any `json:"-"`
+	queries []string
+}
+
+var defaultRoundSummary = map[string]any{"type": "round", "timer": int64(5)}
+
+func (partyFlow *PartyFlow) GetRounds() []*Round {
+	return partyFlow.rounds
+}
+
+// GetRound returns the round being played, if any.
+func (partyFlow *PartyFlow) GetRound() *Round {
+	return partyFlow.round
+}
+
+func (partyFlow *PartyFlow) GetRoundStandings() map[string]Standing {
+	return partyFlow.roundStandings
+}
+
+// OnRoundFinished is called with the final round standings whenever the flow
+// leaves a round.
+func (partyFlow *PartyFlow) OnRoundFinished(cb func(*Round, map[string]Standing)) {
+	partyFlow.onRoundFinished = cb
+}
+
+// SpectatorLayout returns the layout as it is sent to spectators: tagged with
+// the round it belongs to.
+func (partyQuery *PartyQuery) SpectatorLayout() map[string]any {
+	if partyQuery.Layout == nil {
+		return nil
+	}
+
+	layout := maps.Clone(partyQuery.Layout)
+	if _, ok := layout["round"]; !ok && partyQuery.Round != nil {
+		layout["round"] = partyQuery.Round
+	}
+
+	return layout
+}
+
+func (partyFlow *PartyFlow) enterRound() {
+	current := partyFlow.current
+	if current.summary || current.Round == partyFlow.round {
+		return
+	}
+
+	partyFlow.round = current.Round
+	partyFlow.roundStandings = make(map[string]Standing)
+
+	if partyFlow.round != nil {
+		partyFlow.logger.Printf("Round %d/%d <%s>", partyFlow.round.Index, partyFlow.round.Total, partyFlow.round.Name)
+	}
+}
+
+// leaveRound finishes the round being played if next is not part of it, and
+// puts the round summary in front of next.
+func (partyFlow *PartyFlow) leaveRound(next *PartyQuery) *PartyQuery {
+	round := partyFlow.round
+	if round == nil || partyFlow.current.summary || next.Round == round {
+		return next
+	}
+
+	standings := maps.Clone(partyFlow.roundStandings)
+	partyFlow.round = nil
+	partyFlow.onRoundFinished(round, standings)
+
+	if round.Summary == nil {
+		return next
+	}
+
+	moveWhen := make(map[string]any)
+	for key, value := range round.Summary {
+		if key != "type" {
+			moveWhen[key] = value
+		}
+	}
+
+	partyFlow.skipGetWinners = true
+
+	return &PartyQuery{
+		Name: fmt.Sprintf("%s (summary)", round.Name),
+		Layout: map[string]any{"type": "overviewer " + round.Summary["type"].(string),
+			"round": round, "winners": standings, "standings": maps.Clone(partyFlow.standings)},
+		Input:        nil,
+		Overviewer:   nil,
+		NextVariants: []conditionalMove{{to: next, when: moveWhen}},
+		Round:        round,
+		summary:      true,
+	}
+}
+
+func parseRounds(value any, defaultSummary any, nameToQuery map[string]*PartyQuery) ([]*Round, error) {
+	tables, ok := value.([]map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("Rounds should be declared as [[rounds]] tables.")
+	}
+
+	fallback, err := parseRoundSummary(defaultSummary, defaultRoundSummary, "round_summary")
+	if err != nil {
+		return nil, err
+	}
+
+	rounds := make([]*Round, 0, len(tables))
+
+	for i, table := range tables {
+		round := &Round{
+			Index: i + 1,
+			Total: len(tables),
+			Name:  fmt.Sprintf("Round %d", i+1),
+		}
+
+		if name, ok := table["name"]; ok {
+			round.Name = name.(string)
+		}
+
+		round.Summary, err = parseRoundSummary(table["summary"], fallback, round.Name)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, queryName := range anySlice(table["queries"]) {
+			query, exists := nameToQuery[queryName.(string)]
+			if !exists || query.Name == "end" {
+				return nil, fmt.Errorf("PartyQuery <%s> referenced in round <%s> not found.", queryName, round.Name)
+			}
+
+			if query.Round != nil {
+				return nil, fmt.Errorf("PartyQuery <%s> can not be part of both <%s> and <%s> rounds.",
+					queryName, query.Round.Name, round.Name)
+			}
+
+			query.Round = round
+			round.queries = append(round.queries, query.Name)
+		}
+
+		if len(round.queries) == 0 {
+			return nil, fmt.Errorf("Round <%s> has no queries.", round.Name)
+		}
+
+		rounds = append(rounds, round)
+	}
+
+	return rounds, nil
+}
+
+func parseRoundSummary(value any, fallback map[string]any, debugName string) (map[string]any, error) {
+	switch value := value.(type) {
+	case nil:
+		return fallback, nil
+	case bool:
+		if value {
+			return fallback, nil
+		}
+		return nil, nil
+	case map[string]any:
+		if _, typeSpecified := value["type"]; !typeSpecified {
+			return nil, fmt.Errorf("Round summary type unspecified (%s).", debugName)
+		}
+
+		if len(value) == 1 {
+			return nil, fmt.Errorf("At least one move condition for round summary should be included (%s).", debugName)
+		}
+
+		return value, nil
+	}
+
+	return nil, fmt.Errorf("Round summary should be a table or a boolean (%s).", debugName)
+}
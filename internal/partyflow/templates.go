@@ -0,0 +1,301 @@
+package partyflow
+
+import (
+	"cmp"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Strings in layouts and inputs may hold {{ references }} that are resolved
+// right before a query is emitted:
+//
+//	step, players                          current step number and player count
+//	nickname                               nickname of whoever receives the query
+//	leader.nickname, leader.wins,
+//	leader.answer                          the player with the most wins so far
+//	standings                              every winner so far with their wins
+//	var.NAME                               a value from the [variables] table
+//	answer.QUERY                           what the recipient answered to QUERY
+//	answers.QUERY                          everything answered to QUERY
+//	round.index, round.total, round.name   the round being played
+//	round.standings, round.leader.nickname,
+//	round.leader.wins                      standings of the round being played
+//
+// Unknown references fail the load. Anything that can not be resolved yet
+// (no leader, no answer) renders as an empty string.
+
+var templateReference = regexp.MustCompile(`\{\{\s*([A-Za-z0-9_.]+)\s*\}\}`)
+
+var templateNames = []string{
+	"step", "players", "nickname", "standings",
+	"leader.nickname", "leader.wins", "leader.answer",
+}
+
+var roundTemplateNames = []string{
+	"round.index", "round.total", "round.name", "round.standings",
+	"round.leader.nickname", "round.leader.wins",
+}
+
+var templatePrefixes = []string{"var.", "answer.", "answers."}
+
+// TemplateContext is what a room knows about the party that PartyFlow
+// does not.
+type TemplateContext struct {
+	Step      int
+	Players   int
+	Nicknames map[string]string
+	Recipient string
+}
+
+// Prepare localizes a query section for language and renders its templates.
+func (partyFlow *PartyFlow) Prepare(section map[string]any, language string, context TemplateContext) map[string]any {
+	if section == nil {
+		return nil
+	}
+
+	return partyFlow.render(partyFlow.Localize(section, language), context).(map[string]any)
+}
+
+// IsPersonal reports whether a section renders differently per recipient,
+// and so can not be broadcast.
+func (partyFlow *PartyFlow) IsPersonal(section map[string]any) bool {
+	personal := false
+
+	walkStrings(section, func(value string) {
+		for _, match := range templateReference.FindAllStringSubmatch(value, -1) {
+			if match[1] == "nickname" || strings.HasPrefix(match[1], "answer.") {
+				personal = true
+			}
+		}
+	})
+
+	return personal
+}
+
+func (partyFlow *PartyFlow) GetAnswers(queryName string) map[string]string {
+	return partyFlow.answers[queryName]
+}
+
+func (partyFlow *PartyFlow) render(value any, context TemplateContext) any {
+	switch value := value.(type) {
+	case string:
+		return templateReference.ReplaceAllStringFunc(value, func(match string) string {
+			reference := templateReference.FindStringSubmatch(match)[1]
+			return partyFlow.resolve(reference, context)
+		})
+
+	case map[string]any:
+		rendered := make(map[string]any, len(value))
+		for k, v := range value {
+			rendered[k] = partyFlow.render(v, context)
+		}
+		return rendered
+
+	case []any:
+		rendered := make([]any, len(value))
+		for i, v := range value {
+			rendered[i] = partyFlow.render(v, context)
+		}
+		return rendered
+	}
+
+	return value
+}
+
+func (partyFlow *PartyFlow) resolve(reference string, context TemplateContext) string {
+	switch reference {
+	case "step":
+		return fmt.Sprint(context.Step)
+	case "players":
+		return fmt.Sprint(context.Players)
+	case "nickname":
+		return context.Nicknames[context.Recipient]
+	case "standings":
+		return formatStandings(partyFlow.standings, context)
+	}
+
+	if leaderReference, found := strings.CutPrefix(reference, "leader."); found {
+		return resolveLeader(leaderReference, partyFlow.standings, context)
+	}
+
+	if roundReference, found := strings.CutPrefix(reference, "round."); found {
+		round := partyFlow.round
+		if round == nil {
+			return ""
+		}
+
+		switch roundReference {
+		case "index":
+			return fmt.Sprint(round.Index)
+		case "total":
+			return fmt.Sprint(round.Total)
+		case "name":
+			return round.Name
+		case "standings":
+			return formatStandings(partyFlow.roundStandings, context)
+		}
+
+		if leaderReference, found := strings.CutPrefix(roundReference, "leader."); found {
+			return resolveLeader(leaderReference, partyFlow.roundStandings, context)
+		}
+	}
+
+	if name, found := strings.CutPrefix(reference, "var."); found {
+		value, ok := partyFlow.variables[name]
+		if !ok {
+			return ""
+		}
+		return fmt.Sprint(value)
+	}
+
+	if queryName, found := strings.CutPrefix(reference, "answer."); found {
+		return partyFlow.answers[queryName][context.Recipient]
+	}
+
+	if queryName, found := strings.CutPrefix(reference, "answers."); found {
+		answers := partyFlow.answers[queryName]
+		users := make([]string, 0, len(answers))
+		for user := range answers {
+			users = append(users, user)
+		}
+		slices.SortFunc(users, func(a, b string) int {
+			return cmp.Compare(nicknameOf(a, context), nicknameOf(b, context))
+		})
+
+		list := []string{}
+		for _, user := range users {
+			list = append(list, answers[user])
+		}
+		return strings.Join(list, ", ")
+	}
+
+	return ""
+}
+
+func resolveLeader(reference string, standings map[string]Standing, context TemplateContext) string {
+	ranking := rank(standings)
+	if len(ranking) == 0 {
+		return ""
+	}
+
+	leader := ranking[0]
+	switch reference {
+	case "nickname":
+		return nicknameOf(leader, context)
+	case "wins":
+		return fmt.Sprint(standings[leader].WinCount)
+	case "answer":
+		return standings[leader].LastInput
+	}
+
+	return ""
+}
+
+func formatStandings(standings map[string]Standing, context TemplateContext) string {
+	formatted := []string{}
+	for _, user := range rank(standings) {
+		formatted = append(formatted,
+			fmt.Sprintf("%s %d", nicknameOf(user, context), standings[user].WinCount))
+	}
+
+	return strings.Join(formatted, ", ")
+}
+
+// rank orders everybody with at least one win by wins, most first.
+func rank(standings map[string]Standing) []string {
+	users := make([]string, 0, len(standings))
+	for user := range standings {
+		users = append(users, user)
+	}
+
+	slices.SortFunc(users, func(a, b string) int {
+		if wins := cmp.Compare(standings[b].WinCount, standings[a].WinCount); wins != 0 {
+			return wins
+		}
+		return cmp.Compare(a, b)
+	})
+
+	return users
+}
+
+func nicknameOf(user string, context TemplateContext) string {
+	nickname, ok := context.Nicknames[user]
+	if !ok {
+		return user
+	}
+
+	return nickname
+}
+
+// checkTemplates fails on any reference a query section could not resolve.
+func (partyFlow *PartyFlow) checkTemplates(query *PartyQuery, nameToQuery map[string]*PartyQuery) error {
+	var templateError error = nil
+
+	sections := map[string]map[string]any{"layout": query.Layout, "input": query.Input}
+	for _, sectionName := range []string{"layout", "input"} {
+		walkStrings(sections[sectionName], func(value string) {
+			if templateError != nil {
+				return
+			}
+
+			references := templateReference.FindAllStringSubmatch(value, -1)
+			if strings.Count(value, "{{") != len(references) {
+				templateError = fmt.Errorf("Malformed template \"%s\" (%s.%s).", value, query.Name, sectionName)
+				return
+			}
+
+			for _, match := range references {
+				if !partyFlow.isKnownReference(match[1], nameToQuery) {
+					templateError = fmt.Errorf("Unknown template reference <%s> (%s.%s).", match[1], query.Name, sectionName)
+					return
+				}
+			}
+		})
+	}
+
+	return templateError
+}
+
+func (partyFlow *PartyFlow) isKnownReference(reference string, nameToQuery map[string]*PartyQuery) bool {
+	if slices.Contains(templateNames, reference) {
+		return true
+	}
+
+	if slices.Contains(roundTemplateNames, reference) {
+		return len(partyFlow.rounds) > 0
+	}
+
+	for _, prefix := range templatePrefixes {
+		name, found := strings.CutPrefix(reference, prefix)
+		if !found {
+			continue
+		}
+
+		if prefix == "var." {
+			_, declared := partyFlow.variables[name]
+			return declared
+		}
+
+		_, exists := nameToQuery[name]
+		return exists
+	}
+
+	return false
+}
+
+func walkStrings(value any, visit func(string)) {
+	switch value := value.(type) {
+	case string:
+		visit(value)
+	case map[string]any:
+		for _, v := range value {
+			walkStrings(v, visit)
+		}
+	case []any:
+		for _, v := range value {
+			walkStrings(v, visit)
+		}
+	}
+}
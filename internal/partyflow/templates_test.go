@@ -0,0 +1,69 @@
+package partyflow
+
+import (
+	"io"
+	"strings"
+	"testing"
+)
+
+const templated = `
+start = "intro"
+
+[variables]
+rounds = 5
+
+[intro]
+    [intro.layout]
+    type = "basic"
+    title = "Round {{ step }} of {{var.rounds}}"
+    description = "{{leader.nickname}} is in the lead with {{leader.wins}} wins"
+
+    [intro.input]
+    type = "text"
+    correct = "1"
+    title = "{{nickname}}, you said {{answer.intro}}"
+
+        [intro.to.end]
+        timer = 3
+`
+
+func TestTemplates(t *testing.T) {
+	partyFlow, err := New().FromString("templated", templated, io.Discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	partyFlow.standings = map[string]Standing{"a": {WinCount: 3}, "b": {WinCount: 1}}
+	partyFlow.answers = map[string]map[string]string{"intro": {"b": "42"}}
+
+	context := TemplateContext{
+		Step:      2,
+		Nicknames: map[string]string{"a": "Alice", "b": "Bob"},
+		Recipient: "b",
+	}
+
+	layout := partyFlow.Prepare(partyFlow.start.Layout, "", context)
+	if layout["title"] != "Round 2 of 5" || layout["description"] != "Alice is in the lead with 3 wins" {
+		t.Fatalf("unexpected layout %v", layout)
+	}
+
+	if partyFlow.IsPersonal(partyFlow.start.Layout) || !partyFlow.IsPersonal(partyFlow.start.Input) {
+		t.Fatalf("only the input should be personal")
+	}
+
+	input := partyFlow.Prepare(partyFlow.start.Input, "", context)
+	if input["title"] != "Bob, you said 42" {
+		t.Fatalf("unexpected input %v", input)
+	}
+}
+
+func TestUnknownTemplateReference(t *testing.T) {
+	for _, reference := range []string{"{{var.missing}}", "{{answer.nowhere}}", "{{leader.age}}", "{{ step"} {
+		spec := strings.Replace(templated, "{{ step }}", reference, 1)
+
+		_, err := New().FromString("templated", spec, io.Discard)
+		if err == nil {
+			t.Fatalf("%s should fail the load", reference)
+		}
+	}
+}
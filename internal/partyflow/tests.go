@@ -0,0 +1,153 @@
+package partyflow
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// TestCase is a [[tests]] block of a WebPartySpec: scripted answers per
+// query and what the party is expected to look like afterwards. Nil
+// expectations are not checked.
+type TestCase struct {
+	Name      string
+	Seed      uint64
+	Language  string
+	Players   []string
+	Inputs    map[string]map[string]string
+	Path      []string
+	Winners   map[string][]string
+	Standings map[string]int
+}
+
+var derivedQuerySuffixes = []string{" (voting)", " (overviewer)"}
+
+func (partyFlow *PartyFlow) GetTests() []TestCase {
+	return partyFlow.tests
+}
+
+func (partyFlow *PartyFlow) parseTests(value any, nameToQuery map[string]*PartyQuery) (tests []TestCase, err error) {
+	tables, ok := value.([]map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("Tests should be declared as [[tests]] tables.")
+	}
+
+	var test TestCase
+	defer func() {
+		if r := recover(); r != nil {
+			tests, err = nil, fmt.Errorf("Test <%s> holds a value of the wrong type.", test.Name)
+		}
+	}()
+
+	tests = make([]TestCase, 0, len(tables))
+
+	for i, table := range tables {
+		test = TestCase{
+			Name:    fmt.Sprintf("test %d", i+1),
+			Seed:    1,
+			Players: []string{},
+			Inputs:  make(map[string]map[string]string),
+		}
+
+		if name, ok := table["name"]; ok {
+			test.Name = name.(string)
+		}
+
+		if language, ok := table["language"]; ok {
+			test.Language = language.(string)
+		}
+
+		if seed, ok := table["seed"]; ok {
+			test.Seed = uint64(seed.(int64))
+		}
+
+		knownPlayers := make(map[string]any)
+		for _, player := range anySlice(table["players"]) {
+			test.Players = append(test.Players, player.(string))
+			knownPlayers[player.(string)] = nil
+		}
+
+		for queryName, answers := range mapOrNil(table["inputs"]) {
+			if !partyFlow.queryExists(queryName, nameToQuery) {
+				return nil, fmt.Errorf("Test <%s> gives inputs to unknown PartyQuery <%s>.", test.Name, queryName)
+			}
+
+			test.Inputs[queryName] = make(map[string]string)
+			for player, answer := range answers.(map[string]any) {
+				test.Inputs[queryName][player] = fmt.Sprint(answer)
+
+				if _, known := knownPlayers[player]; !known {
+					test.Players = append(test.Players, player)
+					knownPlayers[player] = nil
+				}
+			}
+		}
+
+		if path, ok := table["path"]; ok {
+			test.Path = []string{}
+			for _, queryName := range anySlice(path) {
+				if !partyFlow.queryExists(queryName.(string), nameToQuery) {
+					return nil, fmt.Errorf("Test <%s> expects a path through unknown PartyQuery <%s>.", test.Name, queryName)
+				}
+				test.Path = append(test.Path, queryName.(string))
+			}
+		}
+
+		if winners := mapOrNil(table["winners"]); winners != nil {
+			test.Winners = make(map[string][]string)
+			for queryName, users := range winners {
+				if !partyFlow.queryExists(queryName, nameToQuery) {
+					return nil, fmt.Errorf("Test <%s> expects winners of unknown PartyQuery <%s>.", test.Name, queryName)
+				}
+
+				test.Winners[queryName] = []string{}
+				for _, user := range anySlice(users) {
+					test.Winners[queryName] = append(test.Winners[queryName], user.(string))
+				}
+			}
+		}
+
+		if standings := mapOrNil(table["standings"]); standings != nil {
+			test.Standings = make(map[string]int)
+			for player, winCount := range standings {
+				test.Standings[player] = int(winCount.(int64))
+			}
+		}
+
+		slices.Sort(test.Players)
+		tests = append(tests, test)
+	}
+
+	return tests, nil
+}
+
+// queryExists also accepts the voting, overviewer and round summary queries
+// PartyFlow derives while running.
+func (partyFlow *PartyFlow) queryExists(queryName string, nameToQuery map[string]*PartyQuery) bool {
+	for _, round := range partyFlow.rounds {
+		if round.Summary != nil && queryName == round.Name+" (summary)" {
+			return true
+		}
+	}
+
+	for trimmed := true; trimmed; {
+		trimmed = false
+		for _, suffix := range derivedQuerySuffixes {
+			if strings.HasSuffix(queryName, suffix) {
+				queryName = strings.TrimSuffix(queryName, suffix)
+				trimmed = true
+			}
+		}
+	}
+
+	_, exists := nameToQuery[queryName]
+	return exists
+}
+
+func anySlice(value any) []any {
+	if value != nil {
+		return value.([]any)
+	}
+
+	return nil
+}
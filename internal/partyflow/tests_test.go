@@ -0,0 +1,48 @@
+package partyflow
+
+import (
+	"io"
+	"strings"
+	"testing"
+)
+
+const withTests = `
+start = "guess"
+
+[guess]
+    [guess.input]
+    type = "text"
+    correct = "3"
+
+        [guess.to.end]
+        timer = 3
+
+[[tests]]
+name = "alice wins"
+players = ["alice", "bob"]
+inputs = { guess = { alice = "3", bob = "1" } }
+standings = { alice = 1 }
+`
+
+func TestParseTests(t *testing.T) {
+	partyFlow, err := New().FromString("tests", withTests, io.Discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := partyFlow.GetTests()
+	if len(tests) != 1 || tests[0].Name != "alice wins" || len(tests[0].Players) != 2 || tests[0].Standings["alice"] != 1 {
+		t.Fatalf("unexpected tests %+v", tests)
+	}
+}
+
+func TestMalformedTests(t *testing.T) {
+	for _, malformed := range []string{`players = "alice"`, `standings = { alice = "one" }`, `seed = "one"`} {
+		spec := strings.Replace(withTests, `standings = { alice = 1 }`, malformed, 1)
+
+		partyFlow, err := New().FromString("tests", spec, io.Discard)
+		if err == nil {
+			t.Fatalf("%s should fail the load, got %+v", malformed, partyFlow.GetTests())
+		}
+	}
+}
@@ -0,0 +1,77 @@
+// Package qrcode renders QR codes as PNG and SVG images.
+package qrcode
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+
+	"rsc.io/qr"
+)
+
+// quietZone is the blank margin around a code, in modules, that scanners
+// need to find it.
+const quietZone = 4
+
+// PNG renders content as a square PNG image about size pixels wide. Modules
+// are whole pixels, so the image is never smaller than the code needs.
+func PNG(content string, size int) ([]byte, error) {
+	code, err := qr.Encode(content, qr.M)
+	if err != nil {
+		return nil, err
+	}
+
+	modules := code.Size + 2*quietZone
+	scale := max(size/modules, 1)
+
+	img := image.NewPaletted(image.Rect(0, 0, modules*scale, modules*scale),
+		color.Palette{color.White, color.Black})
+	for y := 0; y < code.Size; y++ {
+		for x := 0; x < code.Size; x++ {
+			if !code.Black(x, y) {
+				continue
+			}
+
+			for dy := 0; dy < scale; dy++ {
+				row := ((y+quietZone)*scale + dy) * img.Stride
+				for dx := 0; dx < scale; dx++ {
+					img.Pix[row+(x+quietZone)*scale+dx] = 1
+				}
+			}
+		}
+	}
+
+	var buffer bytes.Buffer
+	if err := png.Encode(&buffer, img); err != nil {
+		return nil, err
+	}
+
+	return buffer.Bytes(), nil
+}
+
+// SVG renders content as a square SVG image size pixels wide.
+func SVG(content string, size int) ([]byte, error) {
+	code, err := qr.Encode(content, qr.M)
+	if err != nil {
+		return nil, err
+	}
+
+	modules := code.Size + 2*quietZone
+
+	var buffer bytes.Buffer
+	fmt.Fprintf(&buffer, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" shape-rendering="crispEdges">`,
+		size, size, modules, modules)
+	fmt.Fprintf(&buffer, `<rect width="%d" height="%d" fill="#fff"/><path fill="#000" d="`, modules, modules)
+	for y := 0; y < code.Size; y++ {
+		for x := 0; x < code.Size; x++ {
+			if code.Black(x, y) {
+				fmt.Fprintf(&buffer, "M%d %dh1v1h-1z", x+quietZone, y+quietZone)
+			}
+		}
+	}
+	buffer.WriteString(`"/></svg>`)
+
+	return buffer.Bytes(), nil
+}
@@ -0,0 +1,48 @@
+package qrcode
+
+import (
+	"bytes"
+	"image/png"
+	"strings"
+	"testing"
+)
+
+func TestPNG(t *testing.T) {
+	data, err := PNG("https://webparty.fun/room/ABCDEFGHI", 300)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The link needs a version 3 code: 29 modules and the quiet zone make
+	// 37, at 8 pixels each.
+	bounds := img.Bounds()
+	if bounds.Dx() != 296 || bounds.Dy() != 296 {
+		t.Fatalf("image is %v", bounds)
+	}
+
+	isBlack := func(module int) bool {
+		r, _, _, _ := img.At(module*8+4, module*8+4).RGBA()
+		return r == 0
+	}
+	if isBlack(quietZone-1) || !isBlack(quietZone) {
+		t.Error("finder pattern does not start after the quiet zone")
+	}
+}
+
+func TestSVG(t *testing.T) {
+	data, err := SVG("ABCDEFGHI", 256)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	svg := string(data)
+	if !strings.Contains(svg, `width="256"`) || !strings.Contains(svg, `viewBox="0 0 29 29"`) ||
+		!strings.HasSuffix(svg, "</svg>") {
+		t.Errorf("unexpected svg %s", svg)
+	}
+}
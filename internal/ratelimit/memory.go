@@ -0,0 +1,86 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+const sweepInterval = time.Minute
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+	period  time.Duration
+}
+
+// MemoryStore keeps buckets in process, which is enough for a single
+// instance.
+type MemoryStore struct {
+	mutex   sync.Mutex
+	buckets map[string]*bucket
+	swept   time.Time
+	now     func() time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{buckets: make(map[string]*bucket), now: time.Now}
+}
+
+func (s *MemoryStore) Take(ctx context.Context, key string, limit Limit) (Result, error) {
+	return s.take(key, limit, 1), nil
+}
+
+func (s *MemoryStore) Check(ctx context.Context, key string, limit Limit) (Result, error) {
+	return s.take(key, limit, 0), nil
+}
+
+func (s *MemoryStore) Reset(ctx context.Context, key string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	delete(s.buckets, key)
+	return nil
+}
+
+func (s *MemoryStore) take(key string, limit Limit, cost float64) Result {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	now := s.now()
+	s.sweep(now)
+
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(limit.Burst), updated: now}
+	}
+
+	tokens, retryAfter := refill(b.tokens, now.Sub(b.updated), limit)
+	if retryAfter > 0 {
+		return Result{RetryAfter: retryAfter}
+	}
+
+	if cost > 0 {
+		b.tokens = tokens - cost
+		b.updated = now
+		b.period = limit.Period
+		s.buckets[key] = b
+	}
+
+	return Result{Allowed: true}
+}
+
+// sweep drops buckets that have refilled completely, since they behave
+// the same as missing ones.
+func (s *MemoryStore) sweep(now time.Time) {
+	if now.Sub(s.swept) < sweepInterval {
+		return
+	}
+	s.swept = now
+
+	for key, b := range s.buckets {
+		if now.Sub(b.updated) >= b.period {
+			delete(s.buckets, key)
+		}
+	}
+}
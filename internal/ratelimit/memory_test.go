@@ -0,0 +1,52 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMemoryStore(t *testing.T) {
+	now := time.Unix(0, 0)
+	store := NewMemoryStore()
+	store.now = func() time.Time { return now }
+
+	ctx := context.Background()
+	limit := Limit{Burst: 3, Period: 3 * time.Minute}
+
+	for i := 0; i < 3; i++ {
+		if result, _ := store.Take(ctx, "key", limit); !result.Allowed {
+			t.Fatalf("take %d was rejected", i)
+		}
+	}
+
+	result, _ := store.Take(ctx, "key", limit)
+	if result.Allowed || result.RetryAfter != time.Minute {
+		t.Fatalf("take after burst = %+v, want retry after a minute", result)
+	}
+
+	if result, _ := store.Check(ctx, "other", limit); !result.Allowed {
+		t.Fatal("buckets are not separated by key")
+	}
+
+	now = now.Add(30 * time.Second)
+	if result, _ := store.Check(ctx, "key", limit); result.Allowed || result.RetryAfter != 30*time.Second {
+		t.Fatalf("check after half a refill = %+v", result)
+	}
+
+	now = now.Add(30 * time.Second)
+	if result, _ := store.Check(ctx, "key", limit); !result.Allowed {
+		t.Fatal("check after a refill was rejected")
+	}
+	if result, _ := store.Check(ctx, "key", limit); !result.Allowed {
+		t.Fatal("check consumed a token")
+	}
+
+	store.Take(ctx, "key", limit)
+	store.Reset(ctx, "key")
+	for i := 0; i < 3; i++ {
+		if result, _ := store.Take(ctx, "key", limit); !result.Allowed {
+			t.Fatalf("take %d after reset was rejected", i)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package ratelimit
+
+import (
+	"context"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Limit is a token bucket holding up to Burst tokens which refills
+// completely over Period.
+type Limit struct {
+	Burst  int
+	Period time.Duration
+}
+
+type Result struct {
+	Allowed    bool
+	RetryAfter time.Duration
+}
+
+type Store interface {
+	// Take consumes a token of the bucket at key.
+	Take(ctx context.Context, key string, limit Limit) (Result, error)
+	// Check tells whether the bucket at key has a token left without
+	// consuming it.
+	Check(ctx context.Context, key string, limit Limit) (Result, error)
+	Reset(ctx context.Context, key string) error
+}
+
+type Limiter struct {
+	store Store
+}
+
+func NewLimiter(store Store) *Limiter {
+	return &Limiter{store: store}
+}
+
+func (l *Limiter) Take(ctx context.Context, key string, limit Limit) Result {
+	return l.failOpen(l.store.Take(ctx, key, limit))
+}
+
+func (l *Limiter) Check(ctx context.Context, key string, limit Limit) Result {
+	return l.failOpen(l.store.Check(ctx, key, limit))
+}
+
+func (l *Limiter) Reset(ctx context.Context, key string) {
+	if err := l.store.Reset(ctx, key); err != nil {
+		log.Println(err.Error())
+	}
+}
+
+// ByIP limits how often a single client address can call the routes it is
+// used on; name separates the buckets of different routes.
+func (l *Limiter) ByIP(name string, limit Limit) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		result := l.Take(c.Request.Context(), name+":ip:"+c.ClientIP(), limit)
+		if !result.Allowed {
+			Reject(c, result)
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// Reject responds with 429 and tells the client when to retry.
+func Reject(c *gin.Context, result Result) {
+	seconds := int(math.Ceil(result.RetryAfter.Seconds()))
+	if seconds < 1 {
+		seconds = 1
+	}
+
+	c.Header("Retry-After", strconv.Itoa(seconds))
+	c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{
+		"error":       "too many requests",
+		"retry_after": seconds,
+	})
+}
+
+// failOpen lets requests through when the store is unavailable rather than
+// locking everybody out.
+func (l *Limiter) failOpen(result Result, err error) Result {
+	if err != nil {
+		log.Println("rate limit store:", err.Error())
+		return Result{Allowed: true}
+	}
+	return result
+}
+
+// refill returns how many tokens a bucket holds after elapsed time, and how
+// long it takes until it holds one token.
+func refill(tokens float64, elapsed time.Duration, limit Limit) (float64, time.Duration) {
+	rate := float64(limit.Burst) / float64(limit.Period)
+	tokens = math.Min(float64(limit.Burst), tokens+float64(elapsed)*rate)
+
+	if tokens >= 1 {
+		return tokens, 0
+	}
+	return tokens, time.Duration(math.Ceil((1 - tokens) / rate))
+}
@@ -0,0 +1,73 @@
+package ratelimit
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/redis/rueidis"
+)
+
+// takeScript refills and consumes a bucket atomically, using the clock of
+// the Redis server so that all instances agree.
+var takeScript = rueidis.NewLuaScript(`
+local burst = tonumber(ARGV[1])
+local period = tonumber(ARGV[2])
+local cost = tonumber(ARGV[3])
+local time = redis.call('TIME')
+local now = tonumber(time[1]) * 1000 + math.floor(tonumber(time[2]) / 1000)
+
+local bucket = redis.call('HMGET', KEYS[1], 'tokens', 'updated')
+local tokens = tonumber(bucket[1]) or burst
+local updated = tonumber(bucket[2]) or now
+local rate = burst / period
+
+tokens = math.min(burst, tokens + (now - updated) * rate)
+if tokens < 1 then
+  return {0, math.ceil((1 - tokens) / rate)}
+end
+
+if cost > 0 then
+  redis.call('HSET', KEYS[1], 'tokens', tostring(tokens - cost), 'updated', now)
+  redis.call('PEXPIRE', KEYS[1], period)
+end
+return {1, 0}
+`)
+
+// RedisStore shares buckets between all instances of the server.
+type RedisStore struct {
+	client rueidis.Client
+	prefix string
+}
+
+func NewRedisStore(client rueidis.Client, prefix string) *RedisStore {
+	return &RedisStore{client: client, prefix: prefix}
+}
+
+func (s *RedisStore) Take(ctx context.Context, key string, limit Limit) (Result, error) {
+	return s.take(ctx, key, limit, 1)
+}
+
+func (s *RedisStore) Check(ctx context.Context, key string, limit Limit) (Result, error) {
+	return s.take(ctx, key, limit, 0)
+}
+
+func (s *RedisStore) Reset(ctx context.Context, key string) error {
+	return s.client.Do(ctx, s.client.B().Del().Key(s.prefix+key).Build()).Error()
+}
+
+func (s *RedisStore) take(ctx context.Context, key string, limit Limit, cost int) (Result, error) {
+	reply, err := takeScript.Exec(ctx, s.client, []string{s.prefix + key}, []string{
+		strconv.Itoa(limit.Burst),
+		strconv.FormatInt(limit.Period.Milliseconds(), 10),
+		strconv.Itoa(cost),
+	}).AsIntSlice()
+	if err != nil {
+		return Result{}, err
+	}
+
+	return Result{
+		Allowed:    reply[0] == 1,
+		RetryAfter: time.Duration(reply[1]) * time.Millisecond,
+	}, nil
+}
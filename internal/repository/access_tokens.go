@@ -0,0 +1,16 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/theWebPartyTime/server/internal/models"
+)
+
+type AccessTokensRepository interface {
+	CreateToken(ctx context.Context, token *models.PersonalAccessToken) error
+	// GetActiveToken finds a token that is neither revoked nor expired.
+	GetActiveToken(ctx context.Context, tokenHash string) (*models.PersonalAccessToken, error)
+	GetUserTokens(ctx context.Context, userId int) ([]*models.PersonalAccessToken, error)
+	TouchToken(ctx context.Context, id int) error
+	RevokeToken(ctx context.Context, userId int, id int) (bool, error)
+}
@@ -0,0 +1,18 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/theWebPartyTime/server/internal/models"
+)
+
+type AccountDataRepository interface {
+	GetScripts(ctx context.Context, userId int) ([]*models.Script, error)
+	// GetParties returns the parties a user hosted or played in, along with
+	// their own participation only.
+	GetParties(ctx context.Context, userId int) ([]*models.Party, error)
+	GetAnswers(ctx context.Context, userId int) ([]models.PartyAnswer, error)
+	// DeleteUser anonymizes the party records of a user and deletes them.
+	// Their scripts go to transferTo, or are deleted when it is nil.
+	DeleteUser(ctx context.Context, userId int, transferTo *int) error
+}
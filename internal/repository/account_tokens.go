@@ -0,0 +1,12 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/theWebPartyTime/server/internal/models"
+)
+
+type AccountTokensRepository interface {
+	CreateToken(ctx context.Context, token *models.AccountToken) error
+	UseToken(ctx context.Context, purpose models.AccountTokenPurpose, tokenHash string) (*models.AccountToken, error)
+}
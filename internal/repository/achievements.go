@@ -0,0 +1,14 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/theWebPartyTime/server/internal/models"
+)
+
+type AchievementsRepository interface {
+	// Award records achievements of a user and returns the ones they did not
+	// have yet.
+	Award(ctx context.Context, userId int, partyId *int, achievements []string) ([]string, error)
+	GetUserAchievements(ctx context.Context, userId int) ([]models.UserAchievement, error)
+}
@@ -0,0 +1,12 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/theWebPartyTime/server/internal/models"
+)
+
+type EventsRepository interface {
+	AppendEvents(ctx context.Context, events []models.PartyEvent) error
+	GetPartyEvents(ctx context.Context, partyId int, fromSeq int, limit int) ([]models.PartyEvent, error)
+}
@@ -0,0 +1,12 @@
+package repository
+
+import (
+	"context"
+)
+
+// FilesRepository tells whether stored files are still referred to. Files
+// are stored by the hash of their content, so the same one can back an
+// avatar, a cover and an asset at once.
+type FilesRepository interface {
+	FileInUse(ctx context.Context, hash string) (bool, error)
+}
@@ -0,0 +1,17 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/theWebPartyTime/server/internal/models"
+)
+
+var ErrGuestMerged = errors.New("guest was merged already")
+
+type GuestsRepository interface {
+	CreateGuest(ctx context.Context, guest *models.Guest) error
+	// MergeGuest hands the parties a guest played over to an account and
+	// returns how many there were.
+	MergeGuest(ctx context.Context, guestId string, userId int) (int, error)
+}
@@ -0,0 +1,14 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/theWebPartyTime/server/internal/models"
+)
+
+type IdentitiesRepository interface {
+	GetIdentity(ctx context.Context, provider string, subject string) (*models.UserIdentity, error)
+	CreateIdentity(ctx context.Context, identity *models.UserIdentity) error
+	// CreateUserWithIdentity creates a new user along with its first identity.
+	CreateUserWithIdentity(ctx context.Context, user *models.User, identity *models.UserIdentity) error
+}
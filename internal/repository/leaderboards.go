@@ -0,0 +1,15 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/theWebPartyTime/server/internal/models"
+)
+
+type LeaderboardsRepository interface {
+	AddPartyEntries(ctx context.Context, partyId int) error
+	GetLeaderboard(ctx context.Context, scenarioId int, query models.LeaderboardQuery) (*models.Leaderboard, error)
+	ResetEntries(ctx context.Context, scenarioId int, userId int) error
+	ExcludeUser(ctx context.Context, scenarioId int, userId int) error
+	IncludeUser(ctx context.Context, scenarioId int, userId int) error
+}
@@ -0,0 +1,18 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/theWebPartyTime/server/internal/models"
+)
+
+type MFARepository interface {
+	// Enroll stores a pending secret along with new recovery codes.
+	Enroll(ctx context.Context, userId int, secret string, codes []models.RecoveryCode) error
+	Enable(ctx context.Context, userId int) error
+	Disable(ctx context.Context, userId int) error
+	// UseStep records the last accepted time step, failing when a code of
+	// the same or a later step was accepted already.
+	UseStep(ctx context.Context, userId int, step int64) (bool, error)
+	UseRecoveryCode(ctx context.Context, userId int, codeHash string) (bool, error)
+}
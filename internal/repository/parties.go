@@ -0,0 +1,19 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/theWebPartyTime/server/internal/models"
+)
+
+type PartiesRepository interface {
+	CreateParty(ctx context.Context, party *models.Party) error
+	EndParty(ctx context.Context, party *models.Party) error
+	GetUserParties(ctx context.Context, userId int, limit int, offset int) ([]*models.Party, error)
+	GetPartyByID(ctx context.Context, id int) (*models.Party, error)
+	CreateAnswers(ctx context.Context, answers []models.PartyAnswer) error
+	GetPartyAnswers(ctx context.Context, partyId int) ([]models.PartyAnswer, error)
+	GetUserStats(ctx context.Context, userId int, includePrivate bool) (*models.ProfileStats, error)
+	GetScriptAnalytics(ctx context.Context, scenarioId int, from time.Time, to time.Time) (*models.ScriptAnalytics, error)
+}
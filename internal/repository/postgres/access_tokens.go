@@ -0,0 +1,59 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/theWebPartyTime/server/internal/models"
+	"github.com/theWebPartyTime/server/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+type postgresAccessTokensRepo struct {
+	db *gorm.DB
+}
+
+func NewPostgresAccessTokensRepository(db *gorm.DB) repository.AccessTokensRepository {
+	return &postgresAccessTokensRepo{db: db}
+}
+
+func (r *postgresAccessTokensRepo) CreateToken(ctx context.Context, token *models.PersonalAccessToken) error {
+	return r.db.WithContext(ctx).Create(token).Error
+}
+
+func (r *postgresAccessTokensRepo) GetActiveToken(ctx context.Context, tokenHash string) (*models.PersonalAccessToken, error) {
+	var token models.PersonalAccessToken
+	err := r.db.WithContext(ctx).
+		Where("token_hash = ? AND revoked_at IS NULL AND (expires_at IS NULL OR expires_at > ?)", tokenHash, time.Now()).
+		First(&token).Error
+	if err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+func (r *postgresAccessTokensRepo) GetUserTokens(ctx context.Context, userId int) ([]*models.PersonalAccessToken, error) {
+	var tokens []*models.PersonalAccessToken
+	err := r.db.WithContext(ctx).
+		Where("user_id = ? AND revoked_at IS NULL", userId).
+		Order("created_at DESC").
+		Find(&tokens).Error
+	if err != nil {
+		return nil, err
+	}
+	return tokens, nil
+}
+
+func (r *postgresAccessTokensRepo) TouchToken(ctx context.Context, id int) error {
+	return r.db.WithContext(ctx).Model(&models.PersonalAccessToken{}).
+		Where("id = ?", id).
+		Update("last_used_at", time.Now()).Error
+}
+
+func (r *postgresAccessTokensRepo) RevokeToken(ctx context.Context, userId int, id int) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&models.PersonalAccessToken{}).
+		Where("id = ? AND user_id = ? AND revoked_at IS NULL", id, userId).
+		Update("revoked_at", time.Now())
+	return result.RowsAffected > 0, result.Error
+}
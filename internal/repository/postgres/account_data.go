@@ -0,0 +1,127 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/theWebPartyTime/server/internal/models"
+	"github.com/theWebPartyTime/server/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+// deletedNickname replaces the nicknames of deleted users in party records.
+const deletedNickname = "Deleted user"
+
+type postgresAccountDataRepo struct {
+	db *gorm.DB
+}
+
+func NewPostgresAccountDataRepository(db *gorm.DB) repository.AccountDataRepository {
+	return &postgresAccountDataRepo{db: db}
+}
+
+func (r *postgresAccountDataRepo) GetScripts(ctx context.Context, userId int) ([]*models.Script, error) {
+	var scripts []*models.Script
+	err := r.db.WithContext(ctx).Where("creator_id = ?", userId).Order("created_at").Find(&scripts).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return scripts, nil
+}
+
+func (r *postgresAccountDataRepo) GetParties(ctx context.Context, userId int) ([]*models.Party, error) {
+	var parties []*models.Party
+	played := r.db.Model(&models.PartyParticipant{}).Select("party_id").Where("user_id = ?", userId)
+
+	err := r.db.WithContext(ctx).
+		Preload("Participants", "user_id = ?", userId).
+		Where("host_id = ? OR id IN (?)", userId, played).
+		Order("started_at").
+		Find(&parties).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return parties, nil
+}
+
+func (r *postgresAccountDataRepo) GetAnswers(ctx context.Context, userId int) ([]models.PartyAnswer, error) {
+	var answers []models.PartyAnswer
+	err := r.db.WithContext(ctx).Where("user_id = ?", userId).Order("party_id, step").Find(&answers).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return answers, nil
+}
+
+func (r *postgresAccountDataRepo) DeleteUser(ctx context.Context, userId int, transferTo *int) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		scripts := tx.Model(&models.Script{}).Where("creator_id = ?", userId)
+		if transferTo != nil {
+			if err := scripts.Update("creator_id", *transferTo).Error; err != nil {
+				return err
+			}
+		} else if err := tx.Where("creator_id = ?", userId).Delete(&models.Script{}).Error; err != nil {
+			return err
+		}
+
+		if err := scrubEvents(tx, userId); err != nil {
+			return err
+		}
+
+		anonymized := map[string]any{"user_id": nil, "guest_id": nil, "nickname": deletedNickname}
+		err := tx.Model(&models.PartyParticipant{}).Where("user_id = ?", userId).Updates(anonymized).Error
+		if err != nil {
+			return err
+		}
+
+		err = tx.Model(&models.PartyAnswer{}).Where("user_id = ?", userId).Updates(anonymized).Error
+		if err != nil {
+			return err
+		}
+
+		err = tx.Model(&models.Party{}).Where("host_id = ?", userId).Update("host_id", nil).Error
+		if err != nil {
+			return err
+		}
+
+		// Whatever else refers to the user goes along with them or is
+		// unlinked by the foreign keys.
+		return tx.Delete(&models.User{}, userId).Error
+	})
+}
+
+// scrubEvents takes the nicknames and the account of a user out of the event
+// logs of the parties they played in.
+func scrubEvents(tx *gorm.DB, userId int) error {
+	sessions := func() *gorm.DB {
+		return tx.Model(&models.PartyParticipant{}).Select("session_id").Where("user_id = ?", userId)
+	}
+
+	err := tx.Exec(`
+		UPDATE party_events SET data = data || jsonb_build_object('nickname', ?::text)
+		WHERE session_id IN (?) AND data->>'nickname' IS NOT NULL`, deletedNickname, sessions()).Error
+	if err != nil {
+		return err
+	}
+
+	err = tx.Exec(`
+		UPDATE party_events SET data = data || jsonb_build_object('from', ?::text, 'to', ?::text)
+		WHERE session_id IN (?) AND type = 'nickname'`, deletedNickname, deletedNickname, sessions()).Error
+	if err != nil {
+		return err
+	}
+
+	return tx.Exec(`
+		UPDATE party_events SET data = jsonb_set(data, '{participants}', (
+			SELECT jsonb_agg(CASE WHEN p->>'user' IN (?)
+				THEN (p - 'account') || jsonb_build_object('nickname', ?::text)
+				ELSE p END)
+			FROM jsonb_array_elements(data->'participants') p))
+		WHERE type = 'start' AND jsonb_typeof(data->'participants') = 'array'
+			AND jsonb_array_length(data->'participants') > 0
+			AND party_id IN (SELECT party_id FROM party_participants WHERE user_id = ?)`,
+		sessions(), deletedNickname, userId).Error
+}
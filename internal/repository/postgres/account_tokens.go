@@ -0,0 +1,55 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/theWebPartyTime/server/internal/models"
+	"github.com/theWebPartyTime/server/internal/repository"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type postgresAccountTokensRepo struct {
+	db *gorm.DB
+}
+
+func NewPostgresAccountTokensRepository(db *gorm.DB) repository.AccountTokensRepository {
+	return &postgresAccountTokensRepo{db: db}
+}
+
+// CreateToken stores token and invalidates the tokens previously sent to the
+// user for the same purpose, so only the latest email works.
+func (r *postgresAccountTokensRepo) CreateToken(ctx context.Context, token *models.AccountToken) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&models.AccountToken{}).
+			Where("user_id = ? AND purpose = ? AND used_at IS NULL", token.UserId, token.Purpose).
+			Update("used_at", time.Now()).Error
+		if err != nil {
+			return err
+		}
+
+		return tx.Create(token).Error
+	})
+}
+
+// UseToken marks a valid token as used and returns it. Tokens that are
+// unknown, expired or used already give gorm.ErrRecordNotFound.
+func (r *postgresAccountTokensRepo) UseToken(ctx context.Context, purpose models.AccountTokenPurpose, tokenHash string) (*models.AccountToken, error) {
+	var token models.AccountToken
+	now := time.Now()
+
+	result := r.db.WithContext(ctx).Model(&token).
+		Clauses(clause.Returning{}).
+		Where("token_hash = ? AND purpose = ? AND used_at IS NULL AND expires_at > ?", tokenHash, purpose, now).
+		Update("used_at", now)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	return &token, nil
+}
@@ -0,0 +1,52 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/theWebPartyTime/server/internal/models"
+	"github.com/theWebPartyTime/server/internal/repository"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type postgresAchievementsRepo struct {
+	db *gorm.DB
+}
+
+func NewPostgresAchievementsRepository(db *gorm.DB) repository.AchievementsRepository {
+	return &postgresAchievementsRepo{db: db}
+}
+
+func (r *postgresAchievementsRepo) Award(ctx context.Context, userId int, partyId *int, achievements []string) ([]string, error) {
+	awarded := []string{}
+
+	for _, achievement := range achievements {
+		result := r.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&models.UserAchievement{
+			UserId:      userId,
+			Achievement: achievement,
+			PartyId:     partyId,
+			EarnedAt:    time.Now(),
+		})
+		if result.Error != nil {
+			return nil, result.Error
+		}
+
+		if result.RowsAffected > 0 {
+			awarded = append(awarded, achievement)
+		}
+	}
+
+	return awarded, nil
+}
+
+func (r *postgresAchievementsRepo) GetUserAchievements(ctx context.Context, userId int) ([]models.UserAchievement, error) {
+	var achievements []models.UserAchievement
+	err := r.db.WithContext(ctx).Where("user_id = ?", userId).Order("earned_at").Find(&achievements).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return achievements, nil
+}
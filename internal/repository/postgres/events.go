@@ -0,0 +1,39 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/theWebPartyTime/server/internal/models"
+	"github.com/theWebPartyTime/server/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+type postgresEventsRepo struct {
+	db *gorm.DB
+}
+
+func NewPostgresEventsRepository(db *gorm.DB) repository.EventsRepository {
+	return &postgresEventsRepo{db: db}
+}
+
+func (r *postgresEventsRepo) AppendEvents(ctx context.Context, events []models.PartyEvent) error {
+	return r.db.WithContext(ctx).Create(&events).Error
+}
+
+func (r *postgresEventsRepo) GetPartyEvents(ctx context.Context, partyId int, fromSeq int, limit int) ([]models.PartyEvent, error) {
+	var events []models.PartyEvent
+	query := r.db.WithContext(ctx).
+		Where("party_id = ? AND seq >= ?", partyId, fromSeq).
+		Order("seq")
+
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+
+	if err := query.Find(&events).Error; err != nil {
+		return nil, err
+	}
+
+	return events, nil
+}
@@ -0,0 +1,28 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/theWebPartyTime/server/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+type postgresFilesRepo struct {
+	db *gorm.DB
+}
+
+func NewPostgresFilesRepository(db *gorm.DB) repository.FilesRepository {
+	return &postgresFilesRepo{db: db}
+}
+
+func (r *postgresFilesRepo) FileInUse(ctx context.Context, hash string) (bool, error) {
+	var inUse bool
+	err := r.db.WithContext(ctx).Raw(`
+		SELECT EXISTS (SELECT 1 FROM users WHERE avatar_hash = ?)
+			OR EXISTS (SELECT 1 FROM scenarios WHERE script_hash = ? OR cover_hash = ?)
+			OR EXISTS (SELECT 1 FROM assets WHERE file_hash = ?)`,
+		hash, hash, hash, hash).Scan(&inUse).Error
+
+	return inUse, err
+}
@@ -0,0 +1,78 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/theWebPartyTime/server/internal/models"
+	"github.com/theWebPartyTime/server/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+type postgresGuestsRepo struct {
+	db *gorm.DB
+}
+
+func NewPostgresGuestsRepository(db *gorm.DB) repository.GuestsRepository {
+	return &postgresGuestsRepo{db: db}
+}
+
+func (r *postgresGuestsRepo) CreateGuest(ctx context.Context, guest *models.Guest) error {
+	return r.db.WithContext(ctx).Create(guest).Error
+}
+
+func (r *postgresGuestsRepo) MergeGuest(ctx context.Context, guestId string, userId int) (int, error) {
+	var merged int
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.Guest{}).
+			Where("id = ? AND merged_into IS NULL", guestId).
+			Updates(map[string]any{"merged_into": userId, "merged_at": time.Now()})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return repository.ErrGuestMerged
+		}
+
+		result = tx.Model(&models.PartyParticipant{}).
+			Where("guest_id = ? AND user_id IS NULL", guestId).
+			Update("user_id", userId)
+		if result.Error != nil {
+			return result.Error
+		}
+		merged = int(result.RowsAffected)
+
+		err := tx.Model(&models.PartyAnswer{}).
+			Where("guest_id = ? AND user_id IS NULL", guestId).
+			Update("user_id", userId).Error
+		if err != nil {
+			return err
+		}
+
+		// Guests never made it onto leaderboards, their finished parties do
+		// now.
+		var partyIds []int
+		err = tx.Model(&models.PartyParticipant{}).
+			Where("guest_id = ? AND user_id = ?", guestId, userId).
+			Distinct().Pluck("party_id", &partyIds).Error
+		if err != nil {
+			return err
+		}
+
+		leaderboards := NewPostgresLeaderboardsRepository(tx)
+		for _, partyId := range partyIds {
+			if err := leaderboards.AddPartyEntries(ctx, partyId); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return merged, nil
+}
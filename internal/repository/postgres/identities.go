@@ -0,0 +1,44 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/theWebPartyTime/server/internal/models"
+	"github.com/theWebPartyTime/server/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+type postgresIdentitiesRepo struct {
+	db *gorm.DB
+}
+
+func NewPostgresIdentitiesRepository(db *gorm.DB) repository.IdentitiesRepository {
+	return &postgresIdentitiesRepo{db: db}
+}
+
+func (r *postgresIdentitiesRepo) GetIdentity(ctx context.Context, provider string, subject string) (*models.UserIdentity, error) {
+	var identity models.UserIdentity
+	err := r.db.WithContext(ctx).
+		Where("provider = ? AND subject = ?", provider, subject).
+		First(&identity).Error
+	if err != nil {
+		return nil, err
+	}
+	return &identity, nil
+}
+
+func (r *postgresIdentitiesRepo) CreateIdentity(ctx context.Context, identity *models.UserIdentity) error {
+	return r.db.WithContext(ctx).Create(identity).Error
+}
+
+func (r *postgresIdentitiesRepo) CreateUserWithIdentity(ctx context.Context, user *models.User, identity *models.UserIdentity) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(user).Error; err != nil {
+			return err
+		}
+
+		identity.UserId = user.ID
+		return tx.Create(identity).Error
+	})
+}
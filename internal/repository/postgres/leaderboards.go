@@ -0,0 +1,107 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/theWebPartyTime/server/internal/models"
+	"github.com/theWebPartyTime/server/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+type postgresLeaderboardsRepo struct {
+	db *gorm.DB
+}
+
+func NewPostgresLeaderboardsRepository(db *gorm.DB) repository.LeaderboardsRepository {
+	return &postgresLeaderboardsRepo{db: db}
+}
+
+// AddPartyEntries fills the leaderboard of a script with the scores of the
+// registered players of a finished party, skipping excluded ones and those
+// already on it.
+func (r *postgresLeaderboardsRepo) AddPartyEntries(ctx context.Context, partyId int) error {
+	return r.db.WithContext(ctx).Exec(`
+		INSERT INTO leaderboard_entries (scenario_id, script_hash, party_id, user_id, score, achieved_at)
+		SELECT p.scenario_id, p.script_hash, p.id, pp.user_id, max(pp.wins), p.ended_at
+		FROM parties p JOIN party_participants pp ON pp.party_id = p.id
+		WHERE p.id = ? AND p.status = 'finished'
+			AND p.scenario_id IS NOT NULL AND pp.user_id IS NOT NULL
+			AND NOT EXISTS (
+				SELECT 1 FROM leaderboard_exclusions e
+				WHERE e.scenario_id = p.scenario_id AND e.user_id = pp.user_id)
+			AND NOT EXISTS (
+				SELECT 1 FROM leaderboard_entries le
+				WHERE le.party_id = p.id AND le.user_id = pp.user_id)
+		GROUP BY p.id, pp.user_id`, partyId).Error
+}
+
+func (r *postgresLeaderboardsRepo) GetLeaderboard(ctx context.Context, scenarioId int, query models.LeaderboardQuery) (*models.Leaderboard, error) {
+	db := r.db.WithContext(ctx)
+	leaderboard := models.Leaderboard{Period: query.Period, ScriptHash: query.ScriptHash, Rows: []models.LeaderboardRow{}}
+
+	filter := "le.scenario_id = ?"
+	args := []any{scenarioId}
+
+	if query.ScriptHash != "" {
+		filter += " AND le.script_hash = ?"
+		args = append(args, query.ScriptHash)
+	}
+
+	switch query.Period {
+	case models.LeaderboardWeekly:
+		filter += " AND le.achieved_at >= date_trunc('week', now())"
+	case models.LeaderboardMonthly:
+		filter += " AND le.achieved_at >= date_trunc('month', now())"
+	}
+
+	err := db.Raw(`SELECT count(DISTINCT le.user_id) FROM leaderboard_entries le WHERE `+filter, args...).
+		Scan(&leaderboard.Total).Error
+	if err != nil {
+		return nil, err
+	}
+
+	err = db.Raw(`
+		SELECT rank() OVER (ORDER BY best.score DESC) AS rank,
+			best.user_id, u.display_name, best.score, best.parties, best.party_id, best.achieved_at
+		FROM (
+			SELECT DISTINCT ON (le.user_id) le.user_id, le.score, le.party_id, le.achieved_at,
+				count(*) OVER (PARTITION BY le.user_id) AS parties
+			FROM leaderboard_entries le
+			WHERE `+filter+`
+			ORDER BY le.user_id, le.score DESC, le.achieved_at
+		) best JOIN users u ON u.id = best.user_id
+		ORDER BY best.score DESC, best.achieved_at
+		LIMIT ? OFFSET ?`, append(args, query.Limit, query.Offset)...).Scan(&leaderboard.Rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &leaderboard, nil
+}
+
+func (r *postgresLeaderboardsRepo) ResetEntries(ctx context.Context, scenarioId int, userId int) error {
+	return r.db.WithContext(ctx).
+		Exec("DELETE FROM leaderboard_entries WHERE scenario_id = ? AND user_id = ?", scenarioId, userId).Error
+}
+
+// ExcludeUser drops the entries of a user and keeps them off the
+// leaderboard from now on.
+func (r *postgresLeaderboardsRepo) ExcludeUser(ctx context.Context, scenarioId int, userId int) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Exec(`
+			INSERT INTO leaderboard_exclusions (scenario_id, user_id) VALUES (?, ?)
+			ON CONFLICT DO NOTHING`, scenarioId, userId).Error
+		if err != nil {
+			return err
+		}
+
+		return tx.Exec("DELETE FROM leaderboard_entries WHERE scenario_id = ? AND user_id = ?",
+			scenarioId, userId).Error
+	})
+}
+
+func (r *postgresLeaderboardsRepo) IncludeUser(ctx context.Context, scenarioId int, userId int) error {
+	return r.db.WithContext(ctx).
+		Exec("DELETE FROM leaderboard_exclusions WHERE scenario_id = ? AND user_id = ?", scenarioId, userId).Error
+}
@@ -0,0 +1,73 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/theWebPartyTime/server/internal/models"
+	"github.com/theWebPartyTime/server/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+type postgresMFARepo struct {
+	db *gorm.DB
+}
+
+func NewPostgresMFARepository(db *gorm.DB) repository.MFARepository {
+	return &postgresMFARepo{db: db}
+}
+
+func (r *postgresMFARepo) Enroll(ctx context.Context, userId int, secret string, codes []models.RecoveryCode) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&models.User{}).Where("id = ?", userId).Updates(map[string]any{
+			"totp_secret":     secret,
+			"totp_enabled_at": nil,
+			"totp_last_step":  0,
+		}).Error
+		if err != nil {
+			return err
+		}
+
+		if err := tx.Where("user_id = ?", userId).Delete(&models.RecoveryCode{}).Error; err != nil {
+			return err
+		}
+
+		return tx.Create(&codes).Error
+	})
+}
+
+func (r *postgresMFARepo) Enable(ctx context.Context, userId int) error {
+	return r.db.WithContext(ctx).Model(&models.User{}).
+		Where("id = ?", userId).
+		Update("totp_enabled_at", time.Now()).Error
+}
+
+func (r *postgresMFARepo) Disable(ctx context.Context, userId int) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&models.User{}).Where("id = ?", userId).Updates(map[string]any{
+			"totp_secret":     "",
+			"totp_enabled_at": nil,
+			"totp_last_step":  0,
+		}).Error
+		if err != nil {
+			return err
+		}
+
+		return tx.Where("user_id = ?", userId).Delete(&models.RecoveryCode{}).Error
+	})
+}
+
+func (r *postgresMFARepo) UseStep(ctx context.Context, userId int, step int64) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&models.User{}).
+		Where("id = ? AND totp_last_step < ?", userId, step).
+		Update("totp_last_step", step)
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *postgresMFARepo) UseRecoveryCode(ctx context.Context, userId int, codeHash string) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&models.RecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userId, codeHash).
+		Update("used_at", time.Now())
+	return result.RowsAffected > 0, result.Error
+}
@@ -0,0 +1,239 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/theWebPartyTime/server/internal/models"
+	"github.com/theWebPartyTime/server/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+type postgresPartiesRepo struct {
+	db *gorm.DB
+}
+
+func NewPostgresPartiesRepository(db *gorm.DB) repository.PartiesRepository {
+	return &postgresPartiesRepo{db: db}
+}
+
+func (r *postgresPartiesRepo) CreateParty(ctx context.Context, party *models.Party) error {
+	return r.db.WithContext(ctx).Create(party).Error
+}
+
+// EndParty stores the final status of a party and the standings of its
+// participants; participants that joined after the start are added.
+func (r *postgresPartiesRepo) EndParty(ctx context.Context, party *models.Party) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&models.Party{}).Where("id = ?", party.ID).Updates(map[string]any{
+			"status":   party.Status,
+			"ended_at": party.EndedAt,
+		}).Error
+		if err != nil {
+			return err
+		}
+
+		for i := range party.Participants {
+			participant := &party.Participants[i]
+			participant.PartyId = party.ID
+
+			if err := tx.Save(participant).Error; err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+func (r *postgresPartiesRepo) GetUserParties(ctx context.Context, userId int, limit int, offset int) ([]*models.Party, error) {
+	var parties []*models.Party
+	played := r.db.Model(&models.PartyParticipant{}).Select("party_id").Where("user_id = ?", userId)
+
+	err := r.db.WithContext(ctx).
+		Where("host_id = ? OR id IN (?)", userId, played).
+		Order("started_at DESC").
+		Limit(limit).Offset(offset).
+		Find(&parties).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return parties, nil
+}
+
+func (r *postgresPartiesRepo) GetPartyByID(ctx context.Context, id int) (*models.Party, error) {
+	var party models.Party
+	err := r.db.WithContext(ctx).
+		Preload("Participants", func(db *gorm.DB) *gorm.DB {
+			return db.Order("rank, nickname")
+		}).
+		First(&party, id).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &party, nil
+}
+
+func (r *postgresPartiesRepo) CreateAnswers(ctx context.Context, answers []models.PartyAnswer) error {
+	if len(answers) == 0 {
+		return nil
+	}
+
+	return r.db.WithContext(ctx).Create(&answers).Error
+}
+
+func (r *postgresPartiesRepo) GetPartyAnswers(ctx context.Context, partyId int) ([]models.PartyAnswer, error) {
+	var answers []models.PartyAnswer
+	err := r.db.WithContext(ctx).
+		Where("party_id = ?", partyId).
+		Order("step, nickname").
+		Find(&answers).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return answers, nil
+}
+
+const maxProfileScripts = 5
+
+// GetUserStats aggregates the party history of a user. Unless includePrivate
+// is set, only public scripts are listed.
+func (r *postgresPartiesRepo) GetUserStats(ctx context.Context, userId int, includePrivate bool) (*models.ProfileStats, error) {
+	db := r.db.WithContext(ctx)
+	stats := models.ProfileStats{}
+
+	err := db.Raw(`
+		SELECT count(DISTINCT pp.party_id) AS games_played,
+			count(DISTINCT pp.party_id) FILTER (
+				WHERE p.status = 'finished' AND pp.rank = 1 AND pp.wins > 0) AS games_won
+		FROM party_participants pp JOIN parties p ON p.id = pp.party_id
+		WHERE pp.user_id = ?`, userId).Scan(&stats).Error
+	if err != nil {
+		return nil, err
+	}
+
+	err = db.Raw(`SELECT count(*) FROM parties WHERE host_id = ?`, userId).Scan(&stats.GamesHosted).Error
+	if err != nil {
+		return nil, err
+	}
+
+	scripts := `
+		FROM party_participants pp
+			JOIN parties p ON p.id = pp.party_id
+			JOIN scenarios s ON s.id = p.scenario_id
+		WHERE pp.user_id = ? AND (s.public OR ?)
+		GROUP BY s.id`
+
+	err = db.Raw(`
+		SELECT s.id AS scenario_id, s.title, s.script_hash, count(DISTINCT pp.party_id) AS plays`+scripts+`
+		ORDER BY plays DESC, s.title
+		LIMIT ?`, userId, includePrivate, maxProfileScripts).Scan(&stats.FavoriteScripts).Error
+	if err != nil {
+		return nil, err
+	}
+
+	err = db.Raw(`
+		SELECT s.id AS scenario_id, s.title, s.script_hash, max(pp.wins) AS wins`+scripts+`
+		HAVING max(pp.wins) > 0
+		ORDER BY wins DESC, s.title
+		LIMIT ?`, userId, includePrivate, maxProfileScripts).Scan(&stats.BestScores).Error
+	if err != nil {
+		return nil, err
+	}
+
+	if stats.FavoriteScripts == nil {
+		stats.FavoriteScripts = []models.ScriptStats{}
+	}
+	if stats.BestScores == nil {
+		stats.BestScores = []models.ScriptStats{}
+	}
+
+	return &stats, nil
+}
+
+const scriptPartiesFilter = "p.scenario_id = ? AND p.started_at >= ? AND p.started_at < ?"
+
+func (r *postgresPartiesRepo) GetScriptAnalytics(ctx context.Context, scenarioId int, from time.Time, to time.Time) (*models.ScriptAnalytics, error) {
+	db := r.db.WithContext(ctx)
+	analytics := models.ScriptAnalytics{Queries: []models.QueryAnalytics{}}
+
+	err := db.Raw(`
+		SELECT count(*) AS plays,
+			count(*) FILTER (WHERE p.status = 'finished') AS finished,
+			coalesce(avg((SELECT count(*) FROM party_participants pp WHERE pp.party_id = p.id)), 0) AS average_players
+		FROM parties p
+		WHERE `+scriptPartiesFilter, scenarioId, from, to).Scan(&analytics).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var participants int
+	err = db.Raw(`
+		SELECT count(*)
+		FROM party_participants pp JOIN parties p ON p.id = pp.party_id
+		WHERE `+scriptPartiesFilter, scenarioId, from, to).Scan(&participants).Error
+	if err != nil {
+		return nil, err
+	}
+
+	err = db.Raw(`
+		SELECT a.query,
+			count(DISTINCT a.party_id) AS parties,
+			count(*) AS players,
+			count(*) FILTER (WHERE a.answered) AS answers,
+			count(*) FILTER (WHERE a.correct) AS correct,
+			percentile_cont(0.5) WITHIN GROUP (ORDER BY a.response_ms) AS median_response_ms
+		FROM party_answers a JOIN parties p ON p.id = a.party_id
+		WHERE `+scriptPartiesFilter+`
+		GROUP BY a.query
+		ORDER BY min(a.step), a.query`, scenarioId, from, to).Scan(&analytics.Queries).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var distribution []models.AnswerCount
+	err = db.Raw(`
+		SELECT a.query, a.answer, count(*) AS count
+		FROM party_answers a JOIN parties p ON p.id = a.party_id
+		WHERE `+scriptPartiesFilter+` AND a.answered
+		GROUP BY a.query, a.answer
+		ORDER BY count(*) DESC, a.answer`, scenarioId, from, to).Scan(&distribution).Error
+	if err != nil {
+		return nil, err
+	}
+
+	if analytics.Plays > 0 {
+		analytics.CompletionPercent = percent(analytics.Finished, analytics.Plays)
+	}
+
+	for i := range analytics.Queries {
+		query := &analytics.Queries[i]
+		query.Distribution = []models.AnswerCount{}
+		query.PercentCorrect = percent(query.Correct, query.Players)
+		if participants > 0 {
+			query.DropOffPercent = max(0, 100-percent(query.Players, participants))
+		}
+
+		for _, answer := range distribution {
+			if answer.Query == query.Query && len(query.Distribution) < maxDistributionAnswers {
+				query.Distribution = append(query.Distribution, answer)
+			}
+		}
+	}
+
+	return &analytics, nil
+}
+
+const maxDistributionAnswers = 20
+
+func percent(part int, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+
+	return float64(part) * 100 / float64(total)
+}
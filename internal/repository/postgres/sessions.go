@@ -0,0 +1,95 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/theWebPartyTime/server/internal/models"
+	"github.com/theWebPartyTime/server/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+type postgresSessionsRepo struct {
+	db *gorm.DB
+}
+
+func NewPostgresSessionsRepository(db *gorm.DB) repository.SessionsRepository {
+	return &postgresSessionsRepo{db: db}
+}
+
+func (r *postgresSessionsRepo) CreateSession(ctx context.Context, session *models.Session, token *models.RefreshToken) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(session).Error; err != nil {
+			return err
+		}
+
+		token.SessionId = session.ID
+		return tx.Create(token).Error
+	})
+}
+
+func (r *postgresSessionsRepo) GetSession(ctx context.Context, id string) (*models.Session, error) {
+	var session models.Session
+	if err := r.db.WithContext(ctx).Where("id = ?", id).First(&session).Error; err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+func (r *postgresSessionsRepo) GetRefreshToken(ctx context.Context, tokenHash string) (*models.RefreshToken, error) {
+	var token models.RefreshToken
+	if err := r.db.WithContext(ctx).Where("token_hash = ?", tokenHash).First(&token).Error; err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+// RotateRefreshToken marks used as exchanged and stores next in its place,
+// unless used was exchanged concurrently.
+func (r *postgresSessionsRepo) RotateRefreshToken(ctx context.Context, used *models.RefreshToken, next *models.RefreshToken) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		result := tx.Model(&models.RefreshToken{}).
+			Where("id = ? AND used_at IS NULL", used.ID).
+			Update("used_at", now)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return repository.ErrTokenAlreadyUsed
+		}
+
+		err := tx.Model(&models.Session{}).Where("id = ?", used.SessionId).Update("last_used_at", now).Error
+		if err != nil {
+			return err
+		}
+
+		next.SessionId = used.SessionId
+		return tx.Create(next).Error
+	})
+}
+
+func (r *postgresSessionsRepo) GetUserSessions(ctx context.Context, userId int) ([]*models.Session, error) {
+	var sessions []*models.Session
+	err := r.db.WithContext(ctx).
+		Where("user_id = ? AND revoked_at IS NULL", userId).
+		Order("last_used_at DESC").
+		Find(&sessions).Error
+	if err != nil {
+		return nil, err
+	}
+	return sessions, nil
+}
+
+func (r *postgresSessionsRepo) RevokeSession(ctx context.Context, id string) error {
+	return r.db.WithContext(ctx).Model(&models.Session{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Update("revoked_at", time.Now()).Error
+}
+
+func (r *postgresSessionsRepo) RevokeUserSessions(ctx context.Context, userId int, except string) error {
+	return r.db.WithContext(ctx).Model(&models.Session{}).
+		Where("user_id = ? AND id <> ? AND revoked_at IS NULL", userId, except).
+		Update("revoked_at", time.Now()).Error
+}
@@ -39,3 +39,23 @@ func (r *postgresUserRepo) GetUserByEmail(ctx context.Context, email string) (*m
 	return &u, nil
 
 }
+
+func (r *postgresUserRepo) UpdateUser(ctx context.Context, user *models.User, columns ...string) error {
+	return r.db.WithContext(ctx).Model(user).Select(columns).Updates(user).Error
+}
+
+func (r *postgresUserRepo) ListUsers(ctx context.Context, limit int, offset int, search string) ([]*models.User, error) {
+	var users []*models.User
+	query := r.db.WithContext(ctx).Model(&models.User{})
+
+	if search != "" {
+		searchPattern := "%" + search + "%"
+		query = query.Where("email ILIKE ? OR display_name ILIKE ?", searchPattern, searchPattern)
+	}
+
+	err := query.Order("id").Limit(limit).Offset(offset).Find(&users).Error
+	if err != nil {
+		return nil, err
+	}
+	return users, nil
+}
@@ -0,0 +1,22 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/theWebPartyTime/server/internal/models"
+)
+
+// ErrTokenAlreadyUsed is returned by RotateRefreshToken when the token was
+// exchanged before.
+var ErrTokenAlreadyUsed = errors.New("refresh token already used")
+
+type SessionsRepository interface {
+	CreateSession(ctx context.Context, session *models.Session, token *models.RefreshToken) error
+	GetSession(ctx context.Context, id string) (*models.Session, error)
+	GetRefreshToken(ctx context.Context, tokenHash string) (*models.RefreshToken, error)
+	RotateRefreshToken(ctx context.Context, used *models.RefreshToken, next *models.RefreshToken) error
+	GetUserSessions(ctx context.Context, userId int) ([]*models.Session, error)
+	RevokeSession(ctx context.Context, id string) error
+	RevokeUserSessions(ctx context.Context, userId int, except string) error
+}
@@ -10,4 +10,8 @@ type UserRepository interface {
 	CreateUser(ctx context.Context, user *models.User) error
 	GetUserByID(ctx context.Context, id int) (*models.User, error)
 	GetUserByEmail(ctx context.Context, email string) (*models.User, error)
+	// UpdateUser writes only the given columns of user, so columns changed
+	// meanwhile by someone else are kept.
+	UpdateUser(ctx context.Context, user *models.User, columns ...string) error
+	ListUsers(ctx context.Context, limit int, offset int, search string) ([]*models.User, error)
 }
@@ -0,0 +1,153 @@
+package room
+
+import (
+	"errors"
+	"slices"
+	"strconv"
+	"time"
+)
+
+// Ban keeps someone out of a room. ID is the account they were logged in
+// with, or else their guest identity, or else the network they connected
+// from. Bans also hold on to the network, so that logging out or dropping
+// the guest token does not get them back in.
+type Ban struct {
+	ID       string    `json:"id"`
+	Nickname string    `json:"nickname"`
+	Reason   string    `json:"reason,omitempty"`
+	BannedAt time.Time `json:"banned_at"`
+	keys     []string
+}
+
+// SetCohost lets user kick and ban in the room like its owner, or takes that
+// away.
+func (room *room) SetCohost(user string, cohost bool) error {
+	if _, joined := room.nicknames[user]; !joined || room.isOwner(user) {
+		return errors.New("Only players in the room can become co-hosts.")
+	}
+
+	if cohost {
+		room.cohosts[user] = nil
+	} else {
+		delete(room.cohosts, user)
+	}
+
+	room.Record(EventCohost, room.owner, map[string]any{"user": user, "cohost": cohost})
+	return nil
+}
+
+// IsHost reports whether user is the owner or a co-host of the room.
+func (room *room) IsHost(user string) bool {
+	_, cohost := room.cohosts[user]
+	return cohost || room.isOwner(user)
+}
+
+// CanKick reports whether host may kick user out of the room: the owner can
+// not be kicked, and co-hosts can only be kicked by the owner.
+func (room *room) CanKick(host string, user string) bool {
+	if _, joined := room.nicknames[user]; !joined {
+		return false
+	}
+	if !room.IsHost(host) || room.isOwner(user) || host == user {
+		return false
+	}
+
+	return room.isOwner(host) || !room.IsHost(user)
+}
+
+// Kick records that user was sent away for reason and, if ban is set, keeps
+// them from coming back. It returns the ban if there is one.
+func (room *room) Kick(host string, user string, reason string, ban bool) *Ban {
+	var banned *Ban
+	if ban {
+		identity := room.identities[user]
+		banned = &Ban{
+			Nickname: room.nicknames[user],
+			Reason:   reason,
+			BannedAt: time.Now(),
+			keys:     banKeys(identity),
+		}
+
+		if len(banned.keys) > 0 {
+			banned.ID = banned.keys[0]
+			for _, key := range banned.keys {
+				room.bans[key] = banned
+			}
+		} else {
+			banned = nil
+		}
+	}
+
+	room.Record(EventKick, host, map[string]any{"user": user, "reason": reason, "banned": banned != nil})
+	return banned
+}
+
+// Unban lifts the ban with the given ID.
+func (room *room) Unban(host string, id string) error {
+	ban, ok := room.bans[id]
+	if !ok || ban.ID != id {
+		return errors.New("There is no such ban.")
+	}
+
+	// A later ban may have taken over a shared network key.
+	for _, key := range ban.keys {
+		if room.bans[key] == ban {
+			delete(room.bans, key)
+		}
+	}
+
+	room.Record(EventUnban, host, map[string]any{"id": id})
+	return nil
+}
+
+// Bans lists the bans of the room, oldest first.
+func (room *room) Bans() []Ban {
+	bans := []Ban{}
+	for key, ban := range room.bans {
+		if key == ban.ID {
+			bans = append(bans, *ban)
+		}
+	}
+
+	slices.SortFunc(bans, func(a, b Ban) int {
+		return a.BannedAt.Compare(b.BannedAt)
+	})
+
+	return bans
+}
+
+// isBanned checks an account against bans of accounts only, so that others
+// sharing a network with someone banned can still join by logging in.
+// Anyone else is checked against the networks of every ban.
+func (room *room) isBanned(identity Identity) bool {
+	if identity.Authenticated() {
+		_, banned := room.bans[accountKey(identity.Account)]
+		return banned
+	}
+
+	for _, key := range banKeys(identity) {
+		if _, banned := room.bans[key]; banned {
+			return true
+		}
+	}
+
+	return false
+}
+
+func banKeys(identity Identity) []string {
+	keys := []string{}
+	if identity.Authenticated() {
+		keys = append(keys, accountKey(identity.Account))
+	} else if identity.Guest != "" {
+		keys = append(keys, "guest:"+identity.Guest)
+	}
+	if identity.Network != "" {
+		keys = append(keys, "ip:"+identity.Network)
+	}
+
+	return keys
+}
+
+func accountKey(account int) string {
+	return "account:" + strconv.Itoa(account)
+}
@@ -0,0 +1,117 @@
+package room
+
+import (
+	"testing"
+)
+
+func newTestRoom(t *testing.T) *room {
+	room, err := NewManager(DefaultManagerConfig()).Allocate("owner", DefaultRoomConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	room.Joined("owner", "Owner", false)
+	return room
+}
+
+func join(room *room, user string, nickname string, identity Identity) {
+	room.SetIdentity(user, identity)
+	room.Joined(user, nickname, false)
+}
+
+func TestCanKick(t *testing.T) {
+	room := newTestRoom(t)
+	join(room, "cohost", "Cohost", Identity{})
+	join(room, "other-cohost", "Other cohost", Identity{})
+	join(room, "player", "Player", Identity{})
+	join(room, "other-player", "Other player", Identity{})
+	room.SetCohost("cohost", true)
+	room.SetCohost("other-cohost", true)
+
+	cases := []struct {
+		host, user string
+		allowed    bool
+	}{
+		{"owner", "player", true},
+		{"owner", "cohost", true},
+		{"cohost", "player", true},
+		{"cohost", "other-cohost", false},
+		{"cohost", "owner", false},
+		{"player", "other-player", false},
+		{"owner", "owner", false},
+		{"owner", "nobody", false},
+	}
+
+	for _, c := range cases {
+		if allowed := room.CanKick(c.host, c.user); allowed != c.allowed {
+			t.Errorf("CanKick(%q, %q) = %v, want %v", c.host, c.user, allowed, c.allowed)
+		}
+	}
+}
+
+func TestKickAndUnban(t *testing.T) {
+	room := newTestRoom(t)
+	banned := Identity{Account: 1, Network: "home"}
+	join(room, "player", "Player", banned)
+
+	if ban := room.Kick("owner", "player", "", false); ban != nil || room.isBanned(banned) {
+		t.Fatal("kicking without a ban banned the player")
+	}
+
+	ban := room.Kick("owner", "player", "spam", true)
+	if ban == nil || ban.ID != "account:1" || ban.Nickname != "Player" || ban.Reason != "spam" {
+		t.Fatalf("Kick = %+v", ban)
+	}
+
+	cases := []struct {
+		name     string
+		identity Identity
+		banned   bool
+	}{
+		{"same account elsewhere", Identity{Account: 1, Network: "work"}, true},
+		{"other account on the network", Identity{Account: 2, Network: "home"}, false},
+		{"guest on the network", Identity{Guest: "guest", Network: "home"}, true},
+		{"anonymous on the network", Identity{Network: "home"}, true},
+		{"anonymous elsewhere", Identity{Network: "work"}, false},
+	}
+
+	for _, c := range cases {
+		if banned := room.isBanned(c.identity); banned != c.banned {
+			t.Errorf("%s: isBanned = %v, want %v", c.name, banned, c.banned)
+		}
+	}
+
+	if bans := room.Bans(); len(bans) != 1 || bans[0].ID != "account:1" {
+		t.Errorf("Bans = %+v", bans)
+	}
+
+	if err := room.Unban("owner", "ip:home"); err == nil {
+		t.Error("a ban was lifted by its network key")
+	}
+	if err := room.Unban("owner", "account:1"); err != nil {
+		t.Fatal(err)
+	}
+	if room.isBanned(banned) || room.isBanned(Identity{Network: "home"}) || len(room.Bans()) != 0 {
+		t.Error("the ban outlived Unban")
+	}
+}
+
+func TestUnbanSharedNetwork(t *testing.T) {
+	room := newTestRoom(t)
+	join(room, "first", "First", Identity{Guest: "first", Network: "cafe"})
+	join(room, "second", "Second", Identity{Guest: "second", Network: "cafe"})
+
+	room.Kick("owner", "first", "", true)
+	room.Kick("owner", "second", "", true)
+
+	if err := room.Unban("owner", "guest:first"); err != nil {
+		t.Fatal(err)
+	}
+
+	if room.isBanned(Identity{Guest: "first", Network: "elsewhere"}) {
+		t.Error("the lifted ban still applies")
+	}
+	if !room.isBanned(Identity{Network: "cafe"}) {
+		t.Error("lifting one ban lifted the network ban of another")
+	}
+}
@@ -12,7 +12,7 @@ func DefaultRoomConfig() Config {
 		RejectJoins:     true,
 		AutoStart:       false,
 		AllowSpectators: false,
-		AllowAnonymous:  false,
+		AllowAnonymous:  true,
 	}
 }
 
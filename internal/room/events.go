@@ -0,0 +1,56 @@
+package room
+
+import "time"
+
+const (
+	EventJoin           = "join"
+	EventLeave          = "leave"
+	EventNickname       = "nickname"
+	EventConfig         = "config"
+	EventLanguage       = "language"
+	EventStart          = "start"
+	EventQuery          = "query"
+	EventInputAccepted  = "input_accepted"
+	EventInputRejected  = "input_rejected"
+	EventInputWithdrawn = "input_withdrawn"
+	EventWinners        = "winners"
+	EventEnd            = "end"
+	EventCohost         = "cohost"
+	EventKick           = "kick"
+	EventUnban          = "unban"
+)
+
+// Event is a state change of a room. Events are numbered in the order they
+// happen over the whole lifetime of the room.
+type Event struct {
+	Seq  int            `json:"seq"`
+	Type string         `json:"type"`
+	User string         `json:"user,omitempty"`
+	Data map[string]any `json:"data"`
+	At   time.Time      `json:"at"`
+}
+
+// SetOnEvent is called with every event of the room, in order.
+func (room *room) SetOnEvent(onEvent func(Event)) {
+	room.onEvent = onEvent
+}
+
+// Record appends an event to the room log. It is safe to call from the
+// PartyFlow goroutine.
+func (room *room) Record(eventType string, user string, data map[string]any) {
+	room.eventMu.Lock()
+	defer room.eventMu.Unlock()
+
+	if data == nil {
+		data = map[string]any{}
+	}
+
+	room.eventSeq += 1
+	room.onEvent(Event{
+		Seq:  room.eventSeq,
+		Type: eventType,
+		User: user,
+		Data: data,
+		At:   time.Now(),
+	})
+}
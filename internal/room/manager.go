@@ -4,11 +4,13 @@ import (
 	"errors"
 	"log"
 	"math/rand/v2"
+	"slices"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/theWebPartyTime/server/internal/colors"
+	"github.com/theWebPartyTime/server/internal/models"
 )
 
 type ManagerConfig struct {
@@ -60,6 +62,45 @@ func (manager *Manager) Close(roomCode string) {
 	}
 }
 
+// Summary describes a live room for moderation.
+type Summary struct {
+	Code        string    `json:"code"`
+	State       string    `json:"state"`
+	Players     int       `json:"players"`
+	Spectators  int       `json:"spectators"`
+	HostAccount int       `json:"host_account,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// Rooms lists every live room. The caller must hold Mu.
+func (manager *Manager) Rooms() []Summary {
+	summaries := make([]Summary, 0, len(manager.refs.byCode))
+
+	for _, room := range manager.refs.byCode {
+		room.mu.RLock()
+		summary := Summary{
+			Code:       room.code,
+			State:      "open",
+			Players:    room.PlayerCount(),
+			Spectators: len(room.spectators),
+			CreatedAt:  room.createdAt,
+		}
+		if room.state == Ongoing {
+			summary.State = "ongoing"
+		}
+		summary.HostAccount, _ = room.GetAccount(room.owner)
+		room.mu.RUnlock()
+
+		summaries = append(summaries, summary)
+	}
+
+	slices.SortFunc(summaries, func(a, b Summary) int {
+		return a.CreatedAt.Compare(b.CreatedAt)
+	})
+
+	return summaries
+}
+
 func (manager *Manager) Room(roomCode string) (*room, *sync.RWMutex, bool) {
 	var mu *sync.RWMutex = nil
 	room, exists := manager.refs.byCode[roomCode]
@@ -87,10 +128,17 @@ func (manager *Manager) makeRoom(config Config, roomCode string, owner string) *
 		config:         config,
 		state:          Open,
 		nicknames:      make(map[string]string),
-		channels:       map[string]chan any{"input-ready": make(chan any)},
+		channels:       map[string]chan any{"input-ready": make(chan any, 1)},
 		inputs:         make(map[string]Input),
 		nicknameExists: make(map[string]any),
+		spectators:     make(map[string]any),
+		languages:      make(map[string]string),
+		identities:     make(map[string]Identity),
+		cohosts:        make(map[string]any),
+		bans:           make(map[string]*Ban),
 		onStart:        func() {},
+		onPartyStatus:  func(models.PartyStatus) {},
+		onEvent:        func(Event) {},
 		owner:          owner,
 		code:           roomCode,
 		createdAt:      time.Now(),
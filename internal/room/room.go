@@ -1,25 +1,30 @@
 package room
 
 import (
+	"crypto/subtle"
 	"errors"
+	"fmt"
 	"log"
 	"sync"
 	"time"
 
 	"github.com/theWebPartyTime/server/internal/colors"
+	"github.com/theWebPartyTime/server/internal/models"
 	"github.com/theWebPartyTime/server/internal/partyflow"
 )
 
 type Config struct {
-	AllowSpectators bool
-	RejectJoins     bool
-	AllowAnonymous  bool
-	AutoStart       bool
+	AllowSpectators bool   `json:"allow_spectators"`
+	RejectJoins     bool   `json:"reject_joins"`
+	AllowAnonymous  bool   `json:"allow_anonymous"`
+	AutoStart       bool   `json:"auto_start"`
+	Language        string `json:"language"`
 }
 
 type Input struct {
-	Type    string         `json:"type"`
-	Content map[string]any `json:"content"`
+	Type       string         `json:"type"`
+	Content    map[string]any `json:"content"`
+	ReceivedAt time.Time      `json:"-"`
 }
 
 type TypeInput struct {
@@ -28,6 +33,29 @@ type TypeInput struct {
 	Message string `json:"message"`
 }
 
+// Participant is a player of a room; Account is 0 for guests, who may have
+// a Guest identity instead.
+type Participant struct {
+	User     string `json:"user"`
+	Nickname string `json:"nickname"`
+	Account  int    `json:"account,omitempty"`
+	Guest    string `json:"-"`
+}
+
+// Identity is who a connected user is beyond their connection: the account
+// they are logged in with, or else the guest they play as. Both are empty
+// for anonymous users. Network stands for the address they connected from.
+type Identity struct {
+	Account int
+	Guest   string
+	Network string
+}
+
+// Authenticated reports whether the identity is an account.
+func (identity Identity) Authenticated() bool {
+	return identity.Account != 0
+}
+
 type roomState int
 
 const (
@@ -42,50 +70,219 @@ type room struct {
 
 	code           string
 	owner          string
+	pin            string
 	channels       map[string]chan any
 	inputs         map[string]Input
 	nicknames      map[string]string
 	nicknameExists map[string]any
+	spectators     map[string]any
+	languages      map[string]string
+	identities     map[string]Identity
+	cohosts        map[string]any
+	bans           map[string]*Ban
 	createdAt      time.Time
 	partyFlow      *partyflow.PartyFlow
 	onStart        func()
+	onPartyStatus  func(models.PartyStatus)
+	onEvent        func(Event)
+	eventMu        sync.Mutex
+	eventSeq       int
 }
 
-func (room *room) CanJoin(user string, spectatorMode bool) bool {
+// CanJoin reports whether user may join the room. Unless the room allows
+// anonymous users, only those logged in with an account get in; guests are
+// turned away just like users without any identity.
+func (room *room) CanJoin(user string, identity Identity, spectatorMode bool) bool {
 	if room.isOwner(user) {
 		return true
 	}
 
 	return !((room.config.RejectJoins) ||
+		(room.isBanned(identity)) ||
+		(!room.config.AllowAnonymous && !identity.Authenticated()) ||
 		(room.config.AllowSpectators && room.state == Ongoing && !spectatorMode) ||
 		(!room.config.AllowSpectators && spectatorMode))
 }
 
-func (room *room) Joined(user string, nickname string) string {
+func (room *room) Joined(user string, nickname string, spectatorMode bool) string {
 	_, exists := room.nicknameExists[nickname]
 
 	if exists {
 		nickname = nickname + " (" + user[:2] + "...)"
 	}
 
+	previous, rejoined := room.nicknames[user]
+
 	room.nicknameExists[nickname] = nil
 	room.nicknames[user] = nickname
+
+	if spectatorMode {
+		room.spectators[user] = nil
+	} else {
+		delete(room.spectators, user)
+	}
+
+	room.Record(EventJoin, user, map[string]any{"nickname": nickname, "spectator": spectatorMode})
+	if rejoined && previous != nickname {
+		room.Record(EventNickname, user, map[string]any{"from": previous, "to": nickname})
+	}
+
 	return nickname
 }
 
 func (room *room) SetConfig(newConfig Config) {
+	if !room.partyFlow.HasLanguage(newConfig.Language) {
+		newConfig.Language = room.config.Language
+	}
+
 	room.config = newConfig
+	room.Record(EventConfig, room.owner, map[string]any{"config": newConfig})
+}
+
+func (room *room) GetConfig() Config {
+	return room.config
+}
+
+// SetPin makes joining the room take a PIN of 4 to 8 digits; an empty PIN
+// drops it. The PIN is kept out of the config so it never ends up in the
+// event log.
+func (room *room) SetPin(pin string) error {
+	if pin != "" && (len(pin) < 4 || len(pin) > 8) {
+		return errors.New("PIN must be 4 to 8 digits long.")
+	}
+
+	for _, digit := range pin {
+		if digit < '0' || digit > '9' {
+			return errors.New("PIN must only contain digits.")
+		}
+	}
+
+	room.pin = pin
+	return nil
+}
+
+func (room *room) HasPin() bool {
+	return room.pin != ""
+}
+
+// CheckPin reports whether pin opens the room; any pin does without one.
+func (room *room) CheckPin(pin string) bool {
+	if room.pin == "" {
+		return true
+	}
+
+	return subtle.ConstantTimeCompare([]byte(room.pin), []byte(pin)) == 1
+}
+
+// SetLanguage overrides the room language for a single user; an empty
+// language drops the override.
+func (room *room) SetLanguage(user string, language string) error {
+	if language == "" {
+		delete(room.languages, user)
+		room.Record(EventLanguage, user, map[string]any{"language": ""})
+		return nil
+	}
+
+	if !room.partyFlow.HasLanguage(language) {
+		return fmt.Errorf("Language <%s> is not provided by the script.", language)
+	}
+
+	room.languages[user] = language
+	room.Record(EventLanguage, user, map[string]any{"language": language})
+	return nil
+}
+
+func (room *room) GetLanguage(user string) string {
+	language, overridden := room.languages[user]
+	if overridden {
+		return language
+	}
+
+	return room.config.Language
+}
+
+// Recipients maps every player (or spectator) to the language they should
+// receive queries in, and reports whether any of them differs from the room
+// language.
+func (room *room) Recipients(spectators bool) (map[string]string, bool) {
+	recipients := make(map[string]string)
+	overridden := false
+
+	for user := range room.nicknames {
+		_, spectator := room.spectators[user]
+		if spectator != spectators {
+			continue
+		}
+
+		recipients[user] = room.GetLanguage(user)
+		if recipients[user] != room.config.Language {
+			overridden = true
+		}
+	}
+
+	return recipients, overridden
 }
 
 func (room *room) SetOnStart(onStart func()) {
 	room.onStart = onStart
 }
 
+// SetOnPartyStatus is called whenever a party starts in the room and when it
+// finishes or gets cancelled.
+func (room *room) SetOnPartyStatus(onPartyStatus func(models.PartyStatus)) {
+	room.onPartyStatus = onPartyStatus
+}
+
+// SetIdentity links a connected user to the account they are logged in
+// with or the guest they play as.
+func (room *room) SetIdentity(user string, identity Identity) {
+	if identity == (Identity{}) {
+		return
+	}
+
+	room.identities[user] = identity
+}
+
+func (room *room) GetAccount(user string) (int, bool) {
+	identity := room.identities[user]
+	return identity.Account, identity.Authenticated()
+}
+
+func (room *room) GetGuest(user string) (string, bool) {
+	identity := room.identities[user]
+	return identity.Guest, identity.Guest != ""
+}
+
+// Participants lists everyone playing in the room, neither the owner nor
+// spectators.
+func (room *room) Participants() []Participant {
+	participants := []Participant{}
+	for user, nickname := range room.nicknames {
+		_, spectator := room.spectators[user]
+		if spectator || room.isOwner(user) {
+			continue
+		}
+
+		identity := room.identities[user]
+		participants = append(participants, Participant{
+			User: user, Nickname: nickname, Account: identity.Account, Guest: identity.Guest})
+	}
+
+	return participants
+}
+
 func (room *room) Left(user string) {
 	nickname, _ := room.nicknames[user]
 	delete(room.nicknameExists, nickname)
 	delete(room.nicknames, user)
+	delete(room.spectators, user)
+	delete(room.languages, user)
+	if !room.isOwner(user) {
+		delete(room.identities, user)
+	}
+	delete(room.cohosts, user)
 	room.removeInput(user)
+	room.Record(EventLeave, user, map[string]any{"nickname": nickname})
 	log.Printf("[%v] left %v", colors.Left(user), colors.Left(room.GetCode()))
 }
 
@@ -93,12 +290,31 @@ func (room *room) GetNicknames() map[string]string {
 	return room.nicknames
 }
 
+func (room *room) PlayerCount() int {
+	players := 0
+	for user := range room.nicknames {
+		_, spectator := room.spectators[user]
+		if !spectator && !room.isOwner(user) {
+			players += 1
+		}
+	}
+
+	return players
+}
+
 func (room *room) GetCreatedAt() time.Time {
 	return room.createdAt
 }
 
 func (room *room) AttachPartyFlow(partyFlow *partyflow.PartyFlow) {
 	room.partyFlow = partyFlow
+
+	if !partyFlow.HasLanguage(room.config.Language) {
+		room.config.Language = ""
+		if languages := partyFlow.GetLanguages(); len(languages) > 0 {
+			room.config.Language = languages[0]
+		}
+	}
 }
 
 func (room *room) AddChannel(name string, channel chan any) {
@@ -121,9 +337,15 @@ func (room *room) GetInputReadyChannel() chan any {
 func (room *room) AddInput(user string, input Input) {
 	_, ok := room.inputs[user]
 	if !ok {
+		input.ReceivedAt = time.Now()
 		room.inputs[user] = input
+		room.Record(EventInputAccepted, user, map[string]any{"type": input.Type, "content": input.Content})
 	} else if room.state == Open {
 		room.removeInput(user)
+		room.Record(EventInputWithdrawn, user, map[string]any{"type": input.Type})
+	} else {
+		room.Record(EventInputRejected, user, map[string]any{
+			"type": input.Type, "content": input.Content, "reason": "already answered"})
 	}
 
 	room.checkInputsReady()
@@ -169,13 +391,22 @@ func (room *room) checkInputsReady() {
 			}
 		}
 
+		// The signal is kept rather than waited on: the room lock is held
+		// here and the PartyFlow may need it before it listens.
 		if filteredByStep == online {
-			channel, _ := room.channels["input-ready"]
-			channel <- struct{}{}
+			select {
+			case room.channels["input-ready"] <- struct{}{}:
+			default:
+			}
 		}
 	}
 }
 
+// IsOpen reports whether the room is waiting for a party to start.
+func (room *room) IsOpen() bool {
+	return room.state == Open
+}
+
 func (room *room) GetCode() string {
 	return room.code
 }
@@ -191,6 +422,8 @@ func (room *room) Start(restartIfOngoing bool) error {
 
 	if room.state == Open {
 		room.state = Ongoing
+		room.onPartyStatus(models.PartyActive)
+		room.Record(EventStart, room.owner, map[string]any{"participants": room.Participants()})
 		go room.partyFlow.Start()
 
 		log.Printf("--> %v started", colors.RPC(room.code))
@@ -202,6 +435,20 @@ func (room *room) Start(restartIfOngoing bool) error {
 }
 
 func (room *room) Stop() {
+	room.end(models.PartyCancelled)
+}
+
+// Finish stops a room whose PartyFlow reached its end.
+func (room *room) Finish() {
+	room.end(models.PartyFinished)
+}
+
+func (room *room) end(status models.PartyStatus) {
+	if room.state == Ongoing {
+		room.Record(EventEnd, "", map[string]any{"status": status})
+		room.onPartyStatus(status)
+	}
+
 	room.partyFlow.Stop()
 
 	for _, channel := range room.channels {
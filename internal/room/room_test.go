@@ -0,0 +1,44 @@
+package room
+
+import (
+	"testing"
+)
+
+func TestSetPin(t *testing.T) {
+	room := newTestRoom(t)
+
+	for _, pin := range []string{"123", "123456789", "12a4", "١٢٣٤", "12 34"} {
+		if err := room.SetPin(pin); err == nil {
+			t.Errorf("SetPin(%q) was accepted", pin)
+		}
+	}
+	if room.HasPin() {
+		t.Fatal("a rejected PIN was set")
+	}
+
+	for _, pin := range []string{"1234", "12345678"} {
+		if err := room.SetPin(pin); err != nil {
+			t.Errorf("SetPin(%q) = %v", pin, err)
+		}
+	}
+
+	if err := room.SetPin(""); err != nil || room.HasPin() {
+		t.Errorf("SetPin(\"\") = %v, the PIN was not dropped", err)
+	}
+}
+
+func TestCheckPin(t *testing.T) {
+	room := newTestRoom(t)
+
+	if !room.CheckPin("") || !room.CheckPin("0000") {
+		t.Error("a room without a PIN turned a PIN away")
+	}
+
+	room.SetPin("2468")
+	cases := map[string]bool{"2468": true, "": false, "246": false, "24680": false, "1357": false}
+	for pin, opens := range cases {
+		if room.CheckPin(pin) != opens {
+			t.Errorf("CheckPin(%q) = %v, want %v", pin, !opens, opens)
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package room
+
+import (
+	"log"
+	"slices"
+
+	"github.com/theWebPartyTime/server/internal/partyflow"
+)
+
+// Winners judges the inputs collected for a query. For "vote" queries the
+// winner is the most voted message (ties go to the smallest one), otherwise
+// every user whose input passes the query's checker in any language wins.
+func Winners(partyFlow *partyflow.PartyFlow, partyQuery *partyflow.PartyQuery, inputs map[string]Input) []string {
+	winners := []string{}
+	if partyQuery.Input == nil {
+		return winners
+	}
+
+	var voteMap map[string]int = nil
+
+	queryType := partyQuery.Input["type"].(string)
+	if queryType == "vote all" {
+		voteMap = make(map[string]int)
+	}
+
+	users := make([]string, 0, len(inputs))
+	for userID := range inputs {
+		users = append(users, userID)
+	}
+	slices.Sort(users)
+
+	for _, userID := range users {
+		input := inputs[userID]
+		inputType := input.Type
+		if inputType != "input" {
+			log.Printf("Wrong input type sent in by <%s>", userID)
+			continue
+		}
+
+		step, ok := input.Content["step"].(float64)
+		if !ok {
+			log.Printf("Step not specified or specified incorrectly by <%s>", userID)
+			continue
+		}
+
+		message, ok := input.Content["message"].(string)
+		if !ok {
+			log.Printf("Content input not specified or specified incorrectly by <%s>", userID)
+			continue
+		}
+
+		contentType, ok := input.Content["type"].(string)
+		log.Printf("User passed type %v\n", contentType)
+		if !ok {
+			log.Printf("Content input type not specified or specified incorrectly by <%s>", userID)
+			continue
+		}
+
+		if contentType != queryType || step != float64(partyQuery.Step) {
+			log.Printf("User <%s> input relevance check failed: tried step %v, type %v (when need step %v, type %v)",
+				userID, step, contentType, partyQuery.Step, queryType)
+			continue
+		}
+
+		if queryType == "vote all" {
+			_, ok = voteMap[message]
+			if !ok {
+				voteMap[message] = 0
+			}
+			voteMap[message] += 1
+		} else {
+			correct := partyQuery.Input["correct"]
+			checker := partyFlow.GetInputChecker(queryType)
+
+			log.Printf("User sent <%v> to compare against <%v>\n", input.Content, correct)
+
+			answers := partyFlow.Translations(correct)
+
+			if correct == "pick" {
+				translatedLimits := partyFlow.Translations(partyQuery.Input["limits"])
+				limits := translatedLimits[0].([]any)
+				picked := slices.Index(limits, checker.Pick(limits))
+
+				answers = []any{}
+				for _, limits := range translatedLimits {
+					answers = append(answers, limits.([]any)[picked])
+				}
+				log.Printf("Picked correct option to be %v\n", answers)
+			}
+
+			for _, answer := range answers {
+				if checker.IsCorrect(message, answer) {
+					log.Printf("User %v won\n", userID)
+					winners = append(winners, userID)
+					break
+				}
+			}
+		}
+	}
+
+	if voteMap != nil {
+		votingWinner := ""
+		maxVotes := 0
+		for user, votes := range voteMap {
+			if votes > maxVotes || (votes == maxVotes && user < votingWinner) {
+				votingWinner = user
+				maxVotes = votes
+			}
+		}
+
+		log.Printf("Voting concluded with: %v", voteMap)
+
+		if votingWinner != "" {
+			winners = append(winners, votingWinner)
+		}
+	}
+
+	return winners
+}
+
+// Messages returns the message each user sent in with their input.
+func Messages(inputs map[string]Input) map[string]string {
+	res := make(map[string]string)
+
+	for userID, input := range inputs {
+		res[userID] = input.Content["message"].(string)
+	}
+
+	return res
+}
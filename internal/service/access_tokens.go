@@ -0,0 +1,131 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/theWebPartyTime/server/internal/models"
+	"github.com/theWebPartyTime/server/internal/repository"
+)
+
+// AccessTokenPrefix starts every personal access token, which is how they
+// are told apart from JWTs.
+const AccessTokenPrefix = "wpt_"
+
+const (
+	maxTokenNameLength = 64
+	maxTokensPerUser   = 20
+)
+
+var (
+	ErrInvalidTokenName   = errors.New("token name must be 1 to 64 characters")
+	ErrInvalidScope       = errors.New("unknown scope")
+	ErrInvalidExpiry      = errors.New("expiry must be in the future")
+	ErrTooManyTokens      = errors.New("too many access tokens, revoke some first")
+	ErrAccessTokenInvalid = errors.New("invalid access token")
+	ErrTokenNotFound      = errors.New("access token not found")
+)
+
+type AccessTokensService struct {
+	tokensRepo repository.AccessTokensRepository
+	userRepo   repository.UserRepository
+}
+
+func NewAccessTokensService(tokensRepo repository.AccessTokensRepository, userRepo repository.UserRepository) *AccessTokensService {
+	return &AccessTokensService{tokensRepo: tokensRepo, userRepo: userRepo}
+}
+
+// CreateToken returns the new token together with its secret, which is not
+// stored and so can only be shown now.
+func (s *AccessTokensService) CreateToken(ctx context.Context, userId int, req models.CreateAccessToken) (*models.PersonalAccessToken, string, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" || utf8.RuneCountInString(name) > maxTokenNameLength {
+		return nil, "", ErrInvalidTokenName
+	}
+
+	if len(req.Scopes) == 0 {
+		return nil, "", ErrInvalidScope
+	}
+	for _, scope := range req.Scopes {
+		if !slices.Contains(models.Scopes, scope) {
+			return nil, "", ErrInvalidScope
+		}
+	}
+
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		return nil, "", ErrInvalidExpiry
+	}
+
+	tokens, err := s.tokensRepo.GetUserTokens(ctx, userId)
+	if err != nil {
+		return nil, "", err
+	}
+	if len(tokens) >= maxTokensPerUser {
+		return nil, "", ErrTooManyTokens
+	}
+
+	secret, err := randomToken()
+	if err != nil {
+		return nil, "", err
+	}
+	secret = AccessTokenPrefix + secret
+
+	scopes := slices.Clone(req.Scopes)
+	slices.Sort(scopes)
+
+	token := &models.PersonalAccessToken{
+		UserId:    userId,
+		Name:      name,
+		Prefix:    secret[:len(AccessTokenPrefix)+6],
+		TokenHash: hashToken(secret),
+		Scopes:    slices.Compact(scopes),
+		CreatedAt: time.Now(),
+		ExpiresAt: req.ExpiresAt,
+	}
+
+	if err := s.tokensRepo.CreateToken(ctx, token); err != nil {
+		return nil, "", err
+	}
+
+	return token, secret, nil
+}
+
+func (s *AccessTokensService) GetUserTokens(ctx context.Context, userId int) ([]*models.PersonalAccessToken, error) {
+	return s.tokensRepo.GetUserTokens(ctx, userId)
+}
+
+func (s *AccessTokensService) RevokeToken(ctx context.Context, userId int, id int) error {
+	revoked, err := s.tokensRepo.RevokeToken(ctx, userId, id)
+	if err != nil {
+		return err
+	}
+	if !revoked {
+		return ErrTokenNotFound
+	}
+	return nil
+}
+
+// VerifyToken returns who a personal access token acts for and what it may
+// do.
+func (s *AccessTokensService) VerifyToken(ctx context.Context, secret string) (*models.User, []string, error) {
+	token, err := s.tokensRepo.GetActiveToken(ctx, hashToken(secret))
+	if err != nil {
+		return nil, nil, ErrAccessTokenInvalid
+	}
+
+	user, err := s.userRepo.GetUserByID(ctx, token.UserId)
+	if err != nil || user.BannedAt != nil {
+		return nil, nil, ErrAccessTokenInvalid
+	}
+
+	if err := s.tokensRepo.TouchToken(ctx, token.ID); err != nil {
+		log.Println(err.Error())
+	}
+
+	return user, token.Scopes, nil
+}
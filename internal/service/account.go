@@ -0,0 +1,173 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/theWebPartyTime/server/internal/mailer"
+	"github.com/theWebPartyTime/server/internal/models"
+	"github.com/theWebPartyTime/server/internal/repository"
+)
+
+const (
+	verifyEmailTTL   = 48 * time.Hour
+	resetPasswordTTL = time.Hour
+)
+
+var (
+	ErrInvalidAccountToken = errors.New("invalid or expired token")
+	ErrEmptyPassword       = errors.New("password is required")
+	ErrAlreadyVerified     = errors.New("email already verified")
+	ErrEmailNotVerified    = errors.New("email address is not verified")
+)
+
+// AccountService proves that users own their email address, either to verify
+// it or to let them reset a forgotten password.
+type AccountService struct {
+	userRepo     repository.UserRepository
+	tokensRepo   repository.AccountTokensRepository
+	sessionsRepo repository.SessionsRepository
+	mailer       mailer.Mailer
+	appURL       string
+}
+
+func NewAccountService(userRepo repository.UserRepository, tokensRepo repository.AccountTokensRepository, sessionsRepo repository.SessionsRepository, mailer mailer.Mailer, appURL string) *AccountService {
+	return &AccountService{
+		userRepo:     userRepo,
+		tokensRepo:   tokensRepo,
+		sessionsRepo: sessionsRepo,
+		mailer:       mailer,
+		appURL:       appURL,
+	}
+}
+
+func (s *AccountService) SendVerification(ctx context.Context, user *models.User) error {
+	if user.EmailVerifiedAt != nil {
+		return ErrAlreadyVerified
+	}
+
+	token, err := s.createToken(ctx, user.ID, models.VerifyEmail, verifyEmailTTL)
+	if err != nil {
+		return err
+	}
+
+	return s.mailer.Send(ctx, mailer.Message{
+		To:      user.Email,
+		Subject: "Confirm your email address",
+		Body: fmt.Sprintf("Open this link to confirm your email address:\n\n%s/verify-email?token=%s\n\n"+
+			"If you did not create an account, you can ignore this email.", s.appURL, token),
+	})
+}
+
+// ResendVerification mails a new verification link, invalidating the
+// previous one.
+func (s *AccountService) ResendVerification(ctx context.Context, userId int) error {
+	user, err := s.userRepo.GetUserByID(ctx, userId)
+	if err != nil {
+		return ErrUserNotFound
+	}
+
+	return s.SendVerification(ctx, user)
+}
+
+func (s *AccountService) VerifyEmail(ctx context.Context, token string) error {
+	used, err := s.tokensRepo.UseToken(ctx, models.VerifyEmail, hashToken(token))
+	if err != nil {
+		return ErrInvalidAccountToken
+	}
+
+	user, err := s.userRepo.GetUserByID(ctx, used.UserId)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	user.EmailVerifiedAt = &now
+	user.UpdatedAt = now
+	return s.userRepo.UpdateUser(ctx, user, "email_verified_at", "updated_at")
+}
+
+// ForgotPassword mails a reset link if the email belongs to an account. It
+// succeeds either way so the endpoint can not be used to probe for accounts.
+func (s *AccountService) ForgotPassword(ctx context.Context, email string) error {
+	user, err := s.userRepo.GetUserByEmail(ctx, email)
+	if err != nil {
+		return nil
+	}
+
+	token, err := s.createToken(ctx, user.ID, models.ResetPassword, resetPasswordTTL)
+	if err != nil {
+		return err
+	}
+
+	return s.mailer.Send(ctx, mailer.Message{
+		To:      user.Email,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf("Open this link within an hour to choose a new password:\n\n%s/reset-password?token=%s\n\n"+
+			"If you did not ask for this, you can ignore this email.", s.appURL, token),
+	})
+}
+
+// ResetPassword sets a new password and logs the user out everywhere. Having
+// received the reset mail also proves the email address.
+func (s *AccountService) ResetPassword(ctx context.Context, req models.ResetPasswordRequest) error {
+	if req.Password == "" {
+		return ErrEmptyPassword
+	}
+
+	used, err := s.tokensRepo.UseToken(ctx, models.ResetPassword, hashToken(req.Token))
+	if err != nil {
+		return ErrInvalidAccountToken
+	}
+
+	user, err := s.userRepo.GetUserByID(ctx, used.UserId)
+	if err != nil {
+		return err
+	}
+
+	passwordHash, err := HashPassword(req.Password)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	user.PasswordHash = passwordHash
+	user.UpdatedAt = now
+	if user.EmailVerifiedAt == nil {
+		user.EmailVerifiedAt = &now
+	}
+
+	if err := s.userRepo.UpdateUser(ctx, user, "password_hash", "email_verified_at", "updated_at"); err != nil {
+		return err
+	}
+
+	if err := s.sessionsRepo.RevokeUserSessions(ctx, user.ID, ""); err != nil {
+		log.Println(err.Error())
+	}
+
+	return nil
+}
+
+func (s *AccountService) createToken(ctx context.Context, userId int, purpose models.AccountTokenPurpose, ttl time.Duration) (string, error) {
+	token, err := randomToken()
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	err = s.tokensRepo.CreateToken(ctx, &models.AccountToken{
+		UserId:    userId,
+		Purpose:   purpose,
+		TokenHash: hashToken(token),
+		CreatedAt: now,
+		ExpiresAt: now.Add(ttl),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return token, nil
+}
@@ -0,0 +1,208 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/theWebPartyTime/server/internal/mailer"
+	"github.com/theWebPartyTime/server/internal/models"
+	"github.com/theWebPartyTime/server/internal/repository"
+	"github.com/theWebPartyTime/server/internal/storage"
+)
+
+var (
+	ErrInvalidPassword      = errors.New("invalid password")
+	ErrInvalidScriptsPolicy = errors.New("scripts must be either deleted or transferred")
+	ErrTransferTarget       = errors.New("scripts can only be transferred to another account with a verified email address")
+	ErrLoginNotRecent       = errors.New("log in again to delete the account")
+)
+
+// recentLogin is how long after logging in accounts without a password or
+// MFA can still be deleted.
+const recentLogin = 10 * time.Minute
+
+// AccountDataService lets users take their data with them and delete their
+// account.
+type AccountDataService struct {
+	accountDataRepo repository.AccountDataRepository
+	userRepo        repository.UserRepository
+	filesRepo       repository.FilesRepository
+	sessionsRepo    repository.SessionsRepository
+	mfa             *MFAService
+	mailer          mailer.Mailer
+	profiles        *ProfilesService
+	scriptsStorage  storage.FilesStorage
+	imagesStorage   storage.FilesStorage
+}
+
+func NewAccountDataService(accountDataRepo repository.AccountDataRepository, userRepo repository.UserRepository, filesRepo repository.FilesRepository, sessionsRepo repository.SessionsRepository, mfa *MFAService, mailer mailer.Mailer, profiles *ProfilesService, scriptsStorage storage.FilesStorage, imagesStorage storage.FilesStorage) *AccountDataService {
+	return &AccountDataService{
+		accountDataRepo: accountDataRepo,
+		userRepo:        userRepo,
+		filesRepo:       filesRepo,
+		sessionsRepo:    sessionsRepo,
+		mfa:             mfa,
+		mailer:          mailer,
+		profiles:        profiles,
+		scriptsStorage:  scriptsStorage,
+		imagesStorage:   imagesStorage,
+	}
+}
+
+func (s *AccountDataService) Export(ctx context.Context, userId int) (*models.AccountExport, error) {
+	profile, err := s.profiles.GetProfile(ctx, userId, true)
+	if err != nil {
+		return nil, err
+	}
+
+	scripts, err := s.accountDataRepo.GetScripts(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	parties, err := s.accountDataRepo.GetParties(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	answers, err := s.accountDataRepo.GetAnswers(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.AccountExport{
+		ExportedAt: time.Now(),
+		Profile:    profile,
+		Scripts:    scripts,
+		Parties:    parties,
+		Answers:    answers,
+	}, nil
+}
+
+// DeleteAccount deletes a user for good. Their party records stay, under a
+// placeholder nickname, for the other players; their scripts are deleted or
+// transferred to another account as asked. Scripts only go to accounts whose
+// email address was verified, and which get told about them.
+func (s *AccountDataService) DeleteAccount(ctx context.Context, userId int, session string, req models.DeleteAccountRequest) error {
+	user, err := s.userRepo.GetUserByID(ctx, userId)
+	if err != nil {
+		return ErrUserNotFound
+	}
+
+	if err := s.reauthenticate(ctx, user, session, req); err != nil {
+		return err
+	}
+
+	var transferTo *int
+	var recipient *models.User
+	switch req.Scripts {
+	case models.ScriptsDelete, "":
+	case models.ScriptsTransfer:
+		recipient, err = s.userRepo.GetUserByEmail(ctx, strings.TrimSpace(req.TransferTo))
+		if err != nil || recipient == nil || recipient.ID == user.ID || recipient.BannedAt != nil ||
+			recipient.EmailVerifiedAt == nil {
+			return ErrTransferTarget
+		}
+		transferTo = &recipient.ID
+	default:
+		return ErrInvalidScriptsPolicy
+	}
+
+	scripts, err := s.accountDataRepo.GetScripts(ctx, userId)
+	if err != nil {
+		return err
+	}
+
+	if err := s.accountDataRepo.DeleteUser(ctx, userId, transferTo); err != nil {
+		return err
+	}
+
+	if recipient != nil && len(scripts) > 0 {
+		s.notifyTransfer(ctx, recipient, scripts)
+	}
+
+	// Files go only once nothing refers to them anymore, and failing to
+	// delete one leaves an orphan behind rather than the account.
+	images := []string{user.AvatarHash}
+	if transferTo == nil {
+		for _, script := range scripts {
+			deleteUnusedFile(ctx, s.filesRepo, s.scriptsStorage, script.ScriptHash)
+			images = append(images, script.CoverHash)
+		}
+	}
+
+	slices.Sort(images)
+	for _, hash := range slices.Compact(images) {
+		deleteUnusedFile(ctx, s.filesRepo, s.imagesStorage, hash)
+	}
+
+	return nil
+}
+
+// notifyTransfer tells the recipient of the scripts of a deleted account
+// that they own them now. Failing to does not undo the deletion.
+func (s *AccountDataService) notifyTransfer(ctx context.Context, recipient *models.User, scripts []*models.Script) {
+	var titles strings.Builder
+	for _, script := range scripts {
+		titles.WriteString("- " + script.Title + "\n")
+	}
+
+	err := s.mailer.Send(ctx, mailer.Message{
+		To:      recipient.Email,
+		Subject: "Scripts were transferred to you",
+		Body: fmt.Sprintf("An account was deleted and handed its scripts over to you. You now own:\n\n%s\n"+
+			"You can edit, publish or delete them like your own.", titles.String()),
+	})
+	if err != nil {
+		log.Println(err.Error())
+	}
+}
+
+// reauthenticate makes sure that it is the user asking: with their password,
+// or with an MFA code for accounts only logging in through a provider, or
+// else by having logged in just now.
+func (s *AccountDataService) reauthenticate(ctx context.Context, user *models.User, session string, req models.DeleteAccountRequest) error {
+	if user.PasswordHash != "" {
+		if !CheckPasswordHash(req.Password, user.PasswordHash) {
+			return ErrInvalidPassword
+		}
+		return nil
+	}
+
+	if user.TOTPEnabledAt != nil {
+		return s.mfa.Verify(ctx, user, req.Code)
+	}
+
+	current, err := s.sessionsRepo.GetSession(ctx, session)
+	if err != nil || current.UserId != user.ID || time.Since(current.CreatedAt) > recentLogin {
+		return ErrLoginNotRecent
+	}
+
+	return nil
+}
+
+// deleteUnusedFile deletes a stored file unless something still refers to
+// it, since files are shared by content.
+func deleteUnusedFile(ctx context.Context, filesRepo repository.FilesRepository, files storage.FilesStorage, hash string) {
+	if hash == "" {
+		return
+	}
+
+	inUse, err := filesRepo.FileInUse(ctx, hash)
+	if err != nil {
+		log.Println(err.Error())
+		return
+	}
+	if inUse {
+		return
+	}
+
+	if err := files.Delete(ctx, hash); err != nil {
+		log.Println(err.Error())
+	}
+}
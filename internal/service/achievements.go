@@ -0,0 +1,69 @@
+package service
+
+import (
+	"context"
+
+	"github.com/theWebPartyTime/server/internal/achievements"
+	"github.com/theWebPartyTime/server/internal/models"
+	"github.com/theWebPartyTime/server/internal/repository"
+)
+
+type AchievementsService struct {
+	achievementsRepo repository.AchievementsRepository
+	partiesRepo      repository.PartiesRepository
+}
+
+func NewAchievementsService(achievementsRepo repository.AchievementsRepository, partiesRepo repository.PartiesRepository) *AchievementsService {
+	return &AchievementsService{achievementsRepo: achievementsRepo, partiesRepo: partiesRepo}
+}
+
+// Award checks every rule for a user once a party has been recorded and
+// returns the achievements they earned for the first time.
+func (s *AchievementsService) Award(ctx context.Context, userId int, partyId int,
+	party achievements.PartyProgress, finished bool) ([]achievements.Definition, error) {
+
+	stats, err := s.partiesRepo.GetUserStats(ctx, userId, true)
+	if err != nil {
+		return nil, err
+	}
+
+	earned := achievements.Earned(achievements.Progress{Party: party, Finished: finished, Lifetime: *stats})
+	if len(earned) == 0 {
+		return earned, nil
+	}
+
+	ids := make([]string, 0, len(earned))
+	for _, definition := range earned {
+		ids = append(ids, definition.ID)
+	}
+
+	awarded, err := s.achievementsRepo.Award(ctx, userId, &partyId, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	definitions := make([]achievements.Definition, 0, len(awarded))
+	for _, id := range awarded {
+		definition, _ := achievements.Find(id)
+		definitions = append(definitions, definition)
+	}
+
+	return definitions, nil
+}
+
+func (s *AchievementsService) GetUserAchievements(ctx context.Context, userId int) ([]models.UserAchievement, error) {
+	userAchievements, err := s.achievementsRepo.GetUserAchievements(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range userAchievements {
+		definition, ok := achievements.Find(userAchievements[i].Achievement)
+		if ok {
+			userAchievements[i].Title = definition.Title
+			userAchievements[i].Description = definition.Description
+		}
+	}
+
+	return userAchievements, nil
+}
@@ -0,0 +1,160 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/theWebPartyTime/server/internal/models"
+	"github.com/theWebPartyTime/server/internal/repository"
+)
+
+var (
+	ErrInvalidRole     = errors.New("invalid role")
+	ErrCannotModerate  = errors.New("moderators can only act on regular users")
+	ErrCannotActOnSelf = errors.New("you can not do this to your own account")
+)
+
+// AdminService backs the moderation tools. Moderators can ban regular users
+// and unpublish scripts; admins can do anything.
+type AdminService struct {
+	userRepo       repository.UserRepository
+	sessionsRepo   repository.SessionsRepository
+	scriptsRepo    repository.ScriptsRepository
+	scriptsService *ScriptsService
+}
+
+func NewAdminService(userRepo repository.UserRepository, sessionsRepo repository.SessionsRepository, scriptsRepo repository.ScriptsRepository, scriptsService *ScriptsService) *AdminService {
+	return &AdminService{
+		userRepo:       userRepo,
+		sessionsRepo:   sessionsRepo,
+		scriptsRepo:    scriptsRepo,
+		scriptsService: scriptsService,
+	}
+}
+
+func (s *AdminService) ListUsers(ctx context.Context, limit int, offset int, search string) ([]*models.AdminUser, error) {
+	users, err := s.userRepo.ListUsers(ctx, limit, offset, search)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*models.AdminUser, 0, len(users))
+	for _, user := range users {
+		result = append(result, adminUser(user))
+	}
+	return result, nil
+}
+
+// BanUser keeps a user from logging in and ends all of their sessions.
+func (s *AdminService) BanUser(ctx context.Context, actor *models.User, userId int, reason string) (*models.AdminUser, error) {
+	user, err := s.target(ctx, actor, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	user.BannedAt = &now
+	user.BanReason = reason
+	user.UpdatedAt = now
+
+	if err := s.userRepo.UpdateUser(ctx, user, "banned_at", "ban_reason", "updated_at"); err != nil {
+		return nil, err
+	}
+
+	if err := s.sessionsRepo.RevokeUserSessions(ctx, user.ID, ""); err != nil {
+		return nil, err
+	}
+
+	return adminUser(user), nil
+}
+
+func (s *AdminService) UnbanUser(ctx context.Context, actor *models.User, userId int) (*models.AdminUser, error) {
+	user, err := s.target(ctx, actor, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	user.BannedAt = nil
+	user.BanReason = ""
+	user.UpdatedAt = time.Now()
+
+	if err := s.userRepo.UpdateUser(ctx, user, "banned_at", "ban_reason", "updated_at"); err != nil {
+		return nil, err
+	}
+
+	return adminUser(user), nil
+}
+
+func (s *AdminService) SetRole(ctx context.Context, actor *models.User, userId int, role models.Role) (*models.AdminUser, error) {
+	if !role.Valid() {
+		return nil, ErrInvalidRole
+	}
+
+	user, err := s.target(ctx, actor, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	user.Role = role
+	user.UpdatedAt = time.Now()
+
+	if err := s.userRepo.UpdateUser(ctx, user, "role", "updated_at"); err != nil {
+		return nil, err
+	}
+
+	return adminUser(user), nil
+}
+
+// UnpublishScript takes a script off the public list without deleting it
+// for its owner.
+func (s *AdminService) UnpublishScript(ctx context.Context, scriptHash string) error {
+	script, err := s.scriptsRepo.GetScriptByHash(ctx, scriptHash)
+	if err != nil {
+		return ErrScriptNotFound
+	}
+
+	script.Public = false
+	script.UpdatedAt = time.Now()
+	return s.scriptsRepo.UpdateScript(ctx, *script)
+}
+
+func (s *AdminService) DeleteScript(ctx context.Context, scriptHash string) error {
+	if _, err := s.scriptsRepo.GetScriptByHash(ctx, scriptHash); err != nil {
+		return ErrScriptNotFound
+	}
+
+	return s.scriptsService.DeleteScript(ctx, scriptHash)
+}
+
+// target looks up a user actor may act on: never themselves, and only
+// regular users for moderators.
+func (s *AdminService) target(ctx context.Context, actor *models.User, userId int) (*models.User, error) {
+	if actor.ID == userId {
+		return nil, ErrCannotActOnSelf
+	}
+
+	user, err := s.userRepo.GetUserByID(ctx, userId)
+	if err != nil {
+		return nil, ErrUserNotFound
+	}
+
+	if actor.Role != models.RoleAdmin && user.Role != models.RoleUser {
+		return nil, ErrCannotModerate
+	}
+
+	return user, nil
+}
+
+func adminUser(user *models.User) *models.AdminUser {
+	return &models.AdminUser{
+		ID:            user.ID,
+		Email:         user.Email,
+		DisplayName:   user.DisplayName,
+		Role:          user.Role,
+		EmailVerified: user.EmailVerifiedAt != nil,
+		BannedAt:      user.BannedAt,
+		BanReason:     user.BanReason,
+		CreatedAt:     user.CreatedAt,
+	}
+}
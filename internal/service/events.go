@@ -0,0 +1,199 @@
+package service
+
+import (
+	"context"
+	"log"
+	"maps"
+	"sync/atomic"
+	"time"
+
+	"github.com/theWebPartyTime/server/internal/models"
+	"github.com/theWebPartyTime/server/internal/repository"
+	"github.com/theWebPartyTime/server/internal/room"
+)
+
+const (
+	eventsQueueSize     = 4096
+	eventsBatchSize     = 100
+	eventsFlushInterval = 500 * time.Millisecond
+)
+
+type EventsService struct {
+	eventsRepo     repository.EventsRepository
+	partiesService *PartiesService
+	queue          chan models.PartyEvent
+	dropped        atomic.Int64
+}
+
+func NewEventsService(eventsRepo repository.EventsRepository, partiesService *PartiesService) *EventsService {
+	return &EventsService{
+		eventsRepo:     eventsRepo,
+		partiesService: partiesService,
+		queue:          make(chan models.PartyEvent, eventsQueueSize),
+	}
+}
+
+// Append queues an event to be persisted by Run. Events are written in the
+// order they are appended. Rooms call it holding their locks, so it never
+// blocks: when the queue is full the event is dropped.
+func (s *EventsService) Append(event models.PartyEvent) {
+	select {
+	case s.queue <- event:
+	default:
+		if dropped := s.dropped.Add(1); dropped == 1 || dropped%1000 == 0 {
+			log.Printf("party event queue is full, %d events dropped so far", dropped)
+		}
+	}
+}
+
+// Run persists queued events in batches until ctx is done.
+func (s *EventsService) Run(ctx context.Context) {
+	ticker := time.NewTicker(eventsFlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]models.PartyEvent, 0, eventsBatchSize)
+
+	for {
+		select {
+		case event := <-s.queue:
+			batch = append(batch, event)
+			if len(batch) < eventsBatchSize {
+				continue
+			}
+		case <-ticker.C:
+		case <-ctx.Done():
+			s.flush(batch)
+			return
+		}
+
+		s.flush(batch)
+		batch = batch[:0]
+	}
+}
+
+func (s *EventsService) flush(batch []models.PartyEvent) {
+	if len(batch) == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := s.eventsRepo.AppendEvents(ctx, batch); err != nil {
+		log.Printf("failed to persist %d party events: %v", len(batch), err)
+	}
+}
+
+// GetPartyEvents steps through the log of a party, starting at fromSeq. The
+// host gets every event; players do not get what others sent in or were
+// sent privately, so a page may hold fewer than limit events.
+func (s *EventsService) GetPartyEvents(ctx context.Context, partyId int, userId int, fromSeq int, limit int) ([]models.PartyEvent, error) {
+	party, err := s.partiesService.GetParty(ctx, partyId, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	if limit <= 0 {
+		limit = 100
+	}
+	if limit > 1000 {
+		limit = 1000
+	}
+
+	events, err := s.eventsRepo.GetPartyEvents(ctx, partyId, fromSeq, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	viewer := newViewer(party, userId)
+	visible := []models.PartyEvent{}
+	for _, event := range events {
+		if event, ok := viewer.sees(event); ok {
+			visible = append(visible, event)
+		}
+	}
+
+	return visible, nil
+}
+
+// ReplayEvents returns the emitted queries of a party as the user may see
+// them again: the host gets everything, players get what was broadcast and
+// what was sent to them privately.
+func (s *EventsService) ReplayEvents(ctx context.Context, partyId int, userId int, fromSeq int) ([]models.PartyEvent, error) {
+	party, err := s.partiesService.GetParty(ctx, partyId, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	events, err := s.eventsRepo.GetPartyEvents(ctx, partyId, fromSeq, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	viewer := newViewer(party, userId)
+	replay := []models.PartyEvent{}
+	for _, event := range events {
+		if event.Type != room.EventQuery {
+			continue
+		}
+
+		if event, ok := viewer.sees(event); ok {
+			replay = append(replay, event)
+		}
+	}
+
+	return replay, nil
+}
+
+// viewer is someone reading the log of a party: its host, or a player who
+// played under sessions.
+type viewer struct {
+	host     bool
+	sessions map[string]bool
+}
+
+func newViewer(party *models.Party, userId int) viewer {
+	v := viewer{
+		host:     party.HostId != nil && *party.HostId == userId,
+		sessions: make(map[string]bool),
+	}
+
+	for _, participant := range party.Participants {
+		if participant.UserId != nil && *participant.UserId == userId {
+			v.sessions[participant.SessionId] = true
+		}
+	}
+
+	return v
+}
+
+// sees returns event as the viewer may see it. Players only see the queries
+// sent to them privately and their own inputs; what everyone answered is
+// cut down to their own answer.
+func (v viewer) sees(event models.PartyEvent) (models.PartyEvent, bool) {
+	if v.host {
+		return event, true
+	}
+
+	switch event.Type {
+	case room.EventQuery, room.EventInputAccepted, room.EventInputRejected, room.EventInputWithdrawn:
+		return event, event.SessionId == "" || v.sessions[event.SessionId]
+	case room.EventWinners:
+		inputs, ok := event.Data["inputs"].(map[string]any)
+		if !ok {
+			break
+		}
+
+		own := make(map[string]any)
+		for session, input := range inputs {
+			if v.sessions[session] {
+				own[session] = input
+			}
+		}
+
+		event.Data = maps.Clone(event.Data)
+		event.Data["inputs"] = own
+	}
+
+	return event, true
+}
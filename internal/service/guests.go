@@ -0,0 +1,46 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/theWebPartyTime/server/internal/models"
+	"github.com/theWebPartyTime/server/internal/repository"
+)
+
+var ErrGuestAlreadyMerged = errors.New("guest history was merged already")
+
+type GuestsService struct {
+	guestsRepo repository.GuestsRepository
+}
+
+func NewGuestsService(guestsRepo repository.GuestsRepository) *GuestsService {
+	return &GuestsService{guestsRepo: guestsRepo}
+}
+
+// CreateGuest makes a new guest identity to play under without an account.
+func (s *GuestsService) CreateGuest(ctx context.Context) (*models.Guest, error) {
+	guest := &models.Guest{
+		ID:        uuid.NewString(),
+		CreatedAt: time.Now(),
+	}
+
+	if err := s.guestsRepo.CreateGuest(ctx, guest); err != nil {
+		return nil, err
+	}
+
+	return guest, nil
+}
+
+// Merge moves the history of a guest to the account of userId and returns
+// how many parties it held. A guest can only be merged once.
+func (s *GuestsService) Merge(ctx context.Context, guestId string, userId int) (int, error) {
+	merged, err := s.guestsRepo.MergeGuest(ctx, guestId, userId)
+	if errors.Is(err, repository.ErrGuestMerged) {
+		return 0, ErrGuestAlreadyMerged
+	}
+
+	return merged, err
+}
@@ -0,0 +1,107 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/theWebPartyTime/server/internal/models"
+	"github.com/theWebPartyTime/server/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+var ErrInvalidPeriod = errors.New("period must be all, week or month")
+
+type LeaderboardsService struct {
+	leaderboardsRepo repository.LeaderboardsRepository
+	scriptsRepo      repository.ScriptsRepository
+}
+
+func NewLeaderboardsService(leaderboardsRepo repository.LeaderboardsRepository, scriptsRepo repository.ScriptsRepository) *LeaderboardsService {
+	return &LeaderboardsService{leaderboardsRepo: leaderboardsRepo, scriptsRepo: scriptsRepo}
+}
+
+// GetLeaderboard returns a view of the leaderboard of a public script, or of
+// a private one to its owner.
+func (s *LeaderboardsService) GetLeaderboard(ctx context.Context, scriptHash string, userId int, query models.LeaderboardQuery) (*models.Leaderboard, error) {
+	script, err := s.script(ctx, scriptHash)
+	if err != nil {
+		return nil, err
+	}
+
+	if !script.Public && script.CreatorId != userId {
+		return nil, ErrScriptNotFound
+	}
+
+	switch query.Period {
+	case "":
+		query.Period = models.LeaderboardAllTime
+	case models.LeaderboardAllTime, models.LeaderboardWeekly, models.LeaderboardMonthly:
+	default:
+		return nil, ErrInvalidPeriod
+	}
+
+	if query.Limit <= 0 {
+		query.Limit = 20
+	}
+	if query.Limit > 100 {
+		query.Limit = 100
+	}
+	if query.Offset < 0 {
+		query.Offset = 0
+	}
+
+	return s.leaderboardsRepo.GetLeaderboard(ctx, script.ID, query)
+}
+
+// ResetPlayer drops every entry of a player from the leaderboard of a script.
+func (s *LeaderboardsService) ResetPlayer(ctx context.Context, scriptHash string, ownerId int, playerId int) error {
+	script, err := s.ownedScript(ctx, scriptHash, ownerId)
+	if err != nil {
+		return err
+	}
+
+	return s.leaderboardsRepo.ResetEntries(ctx, script.ID, playerId)
+}
+
+// ExcludePlayer resets a player and keeps their future parties off the
+// leaderboard of a script.
+func (s *LeaderboardsService) ExcludePlayer(ctx context.Context, scriptHash string, ownerId int, playerId int) error {
+	script, err := s.ownedScript(ctx, scriptHash, ownerId)
+	if err != nil {
+		return err
+	}
+
+	return s.leaderboardsRepo.ExcludeUser(ctx, script.ID, playerId)
+}
+
+func (s *LeaderboardsService) IncludePlayer(ctx context.Context, scriptHash string, ownerId int, playerId int) error {
+	script, err := s.ownedScript(ctx, scriptHash, ownerId)
+	if err != nil {
+		return err
+	}
+
+	return s.leaderboardsRepo.IncludeUser(ctx, script.ID, playerId)
+}
+
+func (s *LeaderboardsService) script(ctx context.Context, scriptHash string) (*models.Script, error) {
+	script, err := s.scriptsRepo.GetScriptByHash(ctx, scriptHash)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrScriptNotFound
+	}
+
+	return script, err
+}
+
+func (s *LeaderboardsService) ownedScript(ctx context.Context, scriptHash string, ownerId int) (*models.Script, error) {
+	script, err := s.script(ctx, scriptHash)
+	if err != nil {
+		return nil, err
+	}
+
+	if script.CreatorId != ownerId {
+		return nil, ErrNotScriptOwner
+	}
+
+	return script, nil
+}
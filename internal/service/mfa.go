@@ -0,0 +1,170 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base32"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/theWebPartyTime/server/internal/models"
+	"github.com/theWebPartyTime/server/internal/repository"
+	"github.com/theWebPartyTime/server/internal/totp"
+)
+
+const (
+	mfaIssuer         = "WebPartyTime"
+	recoveryCodeCount = 10
+)
+
+var (
+	ErrMFAAlreadyEnabled = errors.New("two-factor authentication is already enabled")
+	ErrMFANotEnrolled    = errors.New("two-factor authentication is not set up")
+	ErrInvalidMFACode    = errors.New("invalid code")
+)
+
+var recoveryEncoding = base32.NewEncoding("abcdefghijklmnopqrstuvwxyz234567").WithPadding(base32.NoPadding)
+
+// MFAService manages TOTP two-factor authentication. Recovery codes stand in
+// for a code once each, for users who lost their authenticator.
+type MFAService struct {
+	userRepo repository.UserRepository
+	mfaRepo  repository.MFARepository
+}
+
+func NewMFAService(userRepo repository.UserRepository, mfaRepo repository.MFARepository) *MFAService {
+	return &MFAService{userRepo: userRepo, mfaRepo: mfaRepo}
+}
+
+// Enroll starts setting up two-factor authentication. It only takes effect
+// once Confirm is called with a code from the authenticator app.
+func (s *MFAService) Enroll(ctx context.Context, userId int) (*models.MFAEnrollment, error) {
+	user, err := s.userRepo.GetUserByID(ctx, userId)
+	if err != nil {
+		return nil, ErrUserNotFound
+	}
+	if user.TOTPEnabledAt != nil {
+		return nil, ErrMFAAlreadyEnabled
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	codes := make([]string, recoveryCodeCount)
+	stored := make([]models.RecoveryCode, recoveryCodeCount)
+	for i := range codes {
+		bytes := make([]byte, 5)
+		if _, err := rand.Read(bytes); err != nil {
+			return nil, err
+		}
+
+		code := recoveryEncoding.EncodeToString(bytes)
+		codes[i] = code[:4] + "-" + code[4:]
+		stored[i] = models.RecoveryCode{UserId: userId, CodeHash: hashToken(code)}
+	}
+
+	if err := s.mfaRepo.Enroll(ctx, userId, secret, stored); err != nil {
+		return nil, err
+	}
+
+	return &models.MFAEnrollment{
+		Secret:        secret,
+		URI:           totp.URI(secret, mfaIssuer, user.Email),
+		RecoveryCodes: codes,
+	}, nil
+}
+
+func (s *MFAService) Confirm(ctx context.Context, userId int, code string) error {
+	user, err := s.userRepo.GetUserByID(ctx, userId)
+	if err != nil {
+		return ErrUserNotFound
+	}
+	if user.TOTPEnabledAt != nil {
+		return ErrMFAAlreadyEnabled
+	}
+	if user.TOTPSecret == "" {
+		return ErrMFANotEnrolled
+	}
+
+	if err := s.checkTOTP(ctx, user, code); err != nil {
+		return err
+	}
+
+	return s.mfaRepo.Enable(ctx, userId)
+}
+
+// Disable turns two-factor authentication off, which takes a valid code
+// just like logging in.
+func (s *MFAService) Disable(ctx context.Context, userId int, code string) error {
+	user, err := s.userRepo.GetUserByID(ctx, userId)
+	if err != nil {
+		return ErrUserNotFound
+	}
+	if user.TOTPEnabledAt == nil {
+		return ErrMFANotEnrolled
+	}
+
+	if err := s.Verify(ctx, user, code); err != nil {
+		return err
+	}
+
+	return s.mfaRepo.Disable(ctx, userId)
+}
+
+// Verify accepts a code from the authenticator app or an unused recovery
+// code.
+func (s *MFAService) Verify(ctx context.Context, user *models.User, code string) error {
+	code = strings.TrimSpace(code)
+
+	if len(code) == 6 {
+		return s.checkTOTP(ctx, user, code)
+	}
+
+	normalized := strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+	used, err := s.mfaRepo.UseRecoveryCode(ctx, user.ID, hashToken(normalized))
+	if err != nil {
+		return err
+	}
+	if !used {
+		return ErrInvalidMFACode
+	}
+
+	return nil
+}
+
+func (s *MFAService) checkTOTP(ctx context.Context, user *models.User, code string) error {
+	step, ok := totp.Validate(user.TOTPSecret, code, time.Now())
+	if !ok {
+		return ErrInvalidMFACode
+	}
+
+	fresh, err := s.mfaRepo.UseStep(ctx, user.ID, step)
+	if err != nil {
+		return err
+	}
+	if !fresh {
+		return ErrInvalidMFACode
+	}
+
+	return nil
+}
+
+// VerifyLogin finishes a login that was held back for a second factor.
+func (s *MFAService) VerifyLogin(ctx context.Context, userId int, code string) (*models.User, error) {
+	user, err := s.userRepo.GetUserByID(ctx, userId)
+	if err != nil {
+		return nil, ErrUserNotFound
+	}
+	if user.TOTPEnabledAt == nil {
+		return nil, ErrMFANotEnrolled
+	}
+
+	if err := s.Verify(ctx, user, code); err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
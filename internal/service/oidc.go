@@ -0,0 +1,172 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/theWebPartyTime/server/internal/models"
+	"github.com/theWebPartyTime/server/internal/oidc"
+	"github.com/theWebPartyTime/server/internal/repository"
+)
+
+var (
+	ErrUnknownProvider      = errors.New("unknown login provider")
+	ErrInvalidOIDCState     = errors.New("login expired, please try again")
+	ErrOIDCEmailMissing     = errors.New("the provider did not share an email address")
+	ErrOIDCEmailNotLinked   = errors.New("an account with this email already exists, log in with your password")
+	ErrOIDCEmailNotVerified = errors.New("the provider has not verified your email address")
+)
+
+// OIDCService logs users in through external OpenID Connect providers.
+type OIDCService struct {
+	providers      map[string]*oidc.Provider
+	flows          *oidc.Flows
+	userRepo       repository.UserRepository
+	identitiesRepo repository.IdentitiesRepository
+	sessionsRepo   repository.SessionsRepository
+}
+
+func NewOIDCService(providers []*oidc.Provider, userRepo repository.UserRepository, identitiesRepo repository.IdentitiesRepository, sessionsRepo repository.SessionsRepository) *OIDCService {
+	byName := make(map[string]*oidc.Provider)
+	for _, provider := range providers {
+		byName[provider.Name()] = provider
+	}
+
+	return &OIDCService{
+		providers:      byName,
+		flows:          oidc.NewFlows(),
+		userRepo:       userRepo,
+		identitiesRepo: identitiesRepo,
+		sessionsRepo:   sessionsRepo,
+	}
+}
+
+// Start begins a login and returns the provider URL to send the user to,
+// along with the state the provider hands back.
+func (s *OIDCService) Start(ctx context.Context, providerName string) (string, string, error) {
+	provider, ok := s.providers[providerName]
+	if !ok {
+		return "", "", ErrUnknownProvider
+	}
+
+	state, flow, err := s.flows.Start(providerName)
+	if err != nil {
+		return "", "", err
+	}
+
+	url, err := provider.AuthURL(ctx, state, flow.Nonce, flow.Verifier)
+	if err != nil {
+		return "", "", err
+	}
+
+	return url, state, nil
+}
+
+// Callback finishes a login and returns the user it belongs to, linking or
+// creating an account on the first login with a provider.
+func (s *OIDCService) Callback(ctx context.Context, providerName string, code string, state string) (*models.User, error) {
+	provider, ok := s.providers[providerName]
+	if !ok {
+		return nil, ErrUnknownProvider
+	}
+
+	flow, ok := s.flows.Take(state)
+	if !ok || flow.Provider != providerName {
+		return nil, ErrInvalidOIDCState
+	}
+
+	claims, err := provider.Exchange(ctx, code, flow.Verifier, flow.Nonce)
+	if err != nil {
+		return nil, err
+	}
+
+	identity, err := s.identitiesRepo.GetIdentity(ctx, providerName, claims.Subject)
+	if err == nil {
+		return s.userRepo.GetUserByID(ctx, identity.UserId)
+	}
+
+	if claims.Email == "" {
+		return nil, ErrOIDCEmailMissing
+	}
+
+	identity = &models.UserIdentity{
+		Provider:  providerName,
+		Subject:   claims.Subject,
+		Email:     claims.Email,
+		CreatedAt: time.Now(),
+	}
+
+	user, err := s.userRepo.GetUserByEmail(ctx, claims.Email)
+	if err == nil {
+		return s.link(ctx, user, identity, claims)
+	}
+
+	return s.create(ctx, identity, claims)
+}
+
+// link adds an identity to the account registered with the same email, which
+// is only safe when the provider has verified that email.
+func (s *OIDCService) link(ctx context.Context, user *models.User, identity *models.UserIdentity, claims *oidc.Claims) (*models.User, error) {
+	if !claims.EmailVerified {
+		return nil, ErrOIDCEmailNotLinked
+	}
+
+	// Nobody proved they own the address of an unverified account, so its
+	// password may have been set by someone else. The provider has now proven
+	// it, so that password and its sessions are dropped.
+	if user.EmailVerifiedAt == nil {
+		now := time.Now()
+		user.EmailVerifiedAt = &now
+		user.PasswordHash = ""
+		user.UpdatedAt = now
+
+		if err := s.userRepo.UpdateUser(ctx, user, "email_verified_at", "password_hash", "updated_at"); err != nil {
+			return nil, err
+		}
+
+		if err := s.sessionsRepo.RevokeUserSessions(ctx, user.ID, ""); err != nil {
+			log.Println(err.Error())
+		}
+	}
+
+	identity.UserId = user.ID
+	if err := s.identitiesRepo.CreateIdentity(ctx, identity); err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// create registers a new account for an identity. Its email must have been
+// verified by the provider, or anyone could claim somebody else's address.
+func (s *OIDCService) create(ctx context.Context, identity *models.UserIdentity, claims *oidc.Claims) (*models.User, error) {
+	if !claims.EmailVerified {
+		return nil, ErrOIDCEmailNotVerified
+	}
+
+	now := time.Now()
+	user := &models.User{
+		Email:           claims.Email,
+		DisplayName:     truncate(strings.TrimSpace(claims.Name), maxDisplayNameLength),
+		CreatedAt:       now,
+		UpdatedAt:       now,
+		EmailVerifiedAt: &now,
+	}
+
+	if err := s.identitiesRepo.CreateUserWithIdentity(ctx, user, identity); err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+func truncate(s string, length int) string {
+	runes := []rune(s)
+	if len(runes) > length {
+		return string(runes[:length])
+	}
+	return s
+}
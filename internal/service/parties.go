@@ -0,0 +1,175 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/theWebPartyTime/server/internal/models"
+	"github.com/theWebPartyTime/server/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+var ErrPartyNotFound = errors.New("party not found")
+var ErrNotPartyHost = errors.New("only the host can access this party")
+var ErrScriptNotFound = errors.New("script not found")
+var ErrNotScriptOwner = errors.New("only the script owner can do this")
+
+type PartiesService struct {
+	partiesRepo      repository.PartiesRepository
+	scriptsRepo      repository.ScriptsRepository
+	leaderboardsRepo repository.LeaderboardsRepository
+}
+
+func NewPartiesService(partiesRepo repository.PartiesRepository, scriptsRepo repository.ScriptsRepository,
+	leaderboardsRepo repository.LeaderboardsRepository) *PartiesService {
+	return &PartiesService{partiesRepo: partiesRepo, scriptsRepo: scriptsRepo, leaderboardsRepo: leaderboardsRepo}
+}
+
+// StartParty records a party that has just started. The script is looked up
+// by hash; rooms may also run scripts that were never uploaded.
+func (s *PartiesService) StartParty(ctx context.Context, party *models.Party) error {
+	script, err := s.scriptsRepo.GetScriptByHash(ctx, party.ScriptHash)
+	if err == nil {
+		party.ScenarioId = &script.ID
+	}
+
+	party.Status = models.PartyActive
+	party.StartedAt = time.Now()
+	party.EndedAt = nil
+
+	return s.partiesRepo.CreateParty(ctx, party)
+}
+
+// EndParty records how a party ended along with the wins of every
+// participant, keyed by session. Finished parties fill the leaderboard of
+// their script.
+func (s *PartiesService) EndParty(ctx context.Context, party *models.Party, status models.PartyStatus, wins map[string]int) error {
+	endedAt := time.Now()
+	party.Status = status
+	party.EndedAt = &endedAt
+
+	for i := range party.Participants {
+		party.Participants[i].Wins = wins[party.Participants[i].SessionId]
+	}
+
+	for i := range party.Participants {
+		rank := 1
+		for _, other := range party.Participants {
+			if other.Wins > party.Participants[i].Wins {
+				rank += 1
+			}
+		}
+		party.Participants[i].Rank = rank
+	}
+
+	if err := s.partiesRepo.EndParty(ctx, party); err != nil {
+		return err
+	}
+
+	if status != models.PartyFinished {
+		return nil
+	}
+
+	return s.leaderboardsRepo.AddPartyEntries(ctx, party.ID)
+}
+
+func (s *PartiesService) GetUserParties(ctx context.Context, userId int, limit int, offset int) ([]*models.Party, error) {
+	if limit <= 0 {
+		limit = 20
+	}
+	if limit > 100 {
+		limit = 100
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	return s.partiesRepo.GetUserParties(ctx, userId, limit, offset)
+}
+
+// GetParty returns a party only to its host and participants.
+func (s *PartiesService) GetParty(ctx context.Context, id int, userId int) (*models.Party, error) {
+	party, err := s.partiesRepo.GetPartyByID(ctx, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrPartyNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if party.HostId != nil && *party.HostId == userId {
+		return party, nil
+	}
+
+	for _, participant := range party.Participants {
+		if participant.UserId != nil && *participant.UserId == userId {
+			return party, nil
+		}
+	}
+
+	return nil, ErrPartyNotFound
+}
+
+// RecordAnswers stores what every participant answered to a step.
+func (s *PartiesService) RecordAnswers(ctx context.Context, answers []models.PartyAnswer) error {
+	return s.partiesRepo.CreateAnswers(ctx, answers)
+}
+
+// GetResults lists every answer of a party along with the final rank of
+// whoever gave it. Only the host may export them.
+func (s *PartiesService) GetResults(ctx context.Context, id int, userId int) ([]models.PartyResult, error) {
+	party, err := s.GetParty(ctx, id, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	if party.HostId == nil || *party.HostId != userId {
+		return nil, ErrNotPartyHost
+	}
+
+	answers, err := s.partiesRepo.GetPartyAnswers(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	ranks := make(map[string]int)
+	for _, participant := range party.Participants {
+		ranks[participant.SessionId] = participant.Rank
+	}
+
+	results := make([]models.PartyResult, 0, len(answers))
+	for _, answer := range answers {
+		results = append(results, models.PartyResult{
+			Nickname:   answer.Nickname,
+			Step:       answer.Step,
+			Query:      answer.Query,
+			Answer:     answer.Answer,
+			Correct:    answer.Correct,
+			Points:     answer.Points,
+			ResponseMs: answer.ResponseMs,
+			Rank:       ranks[answer.SessionId],
+		})
+	}
+
+	return results, nil
+}
+
+// GetScriptAnalytics aggregates the parties of a script, across all of its
+// versions, started within [from, to). Only the script owner may see them.
+func (s *PartiesService) GetScriptAnalytics(ctx context.Context, scriptHash string, userId int, from time.Time, to time.Time) (*models.ScriptAnalytics, error) {
+	script, err := s.scriptsRepo.GetScriptByHash(ctx, scriptHash)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrScriptNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if script.CreatorId != userId {
+		return nil, ErrNotScriptOwner
+	}
+
+	return s.partiesRepo.GetScriptAnalytics(ctx, script.ID, from, to)
+}
@@ -0,0 +1,145 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/theWebPartyTime/server/internal/models"
+	"github.com/theWebPartyTime/server/internal/repository"
+	"github.com/theWebPartyTime/server/internal/storage"
+
+	"gorm.io/gorm"
+)
+
+const (
+	maxDisplayNameLength = 32
+	maxLanguageLength    = 16
+)
+
+var (
+	ErrUserNotFound       = errors.New("user not found")
+	ErrDisplayNameTooLong = errors.New("display name is too long")
+	ErrInvalidLanguage    = errors.New("invalid language")
+	ErrAvatarNotImage     = errors.New("avatar must be an image")
+)
+
+type ProfilesService struct {
+	userRepo      repository.UserRepository
+	partiesRepo   repository.PartiesRepository
+	filesRepo     repository.FilesRepository
+	imagesStorage storage.FilesStorage
+}
+
+func NewProfilesService(userRepo repository.UserRepository, partiesRepo repository.PartiesRepository, filesRepo repository.FilesRepository, imagesStorage storage.FilesStorage) *ProfilesService {
+	return &ProfilesService{userRepo: userRepo, partiesRepo: partiesRepo, filesRepo: filesRepo, imagesStorage: imagesStorage}
+}
+
+func (s *ProfilesService) GetUser(ctx context.Context, id int) (*models.User, error) {
+	user, err := s.userRepo.GetUserByID(ctx, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrUserNotFound
+	}
+
+	return user, err
+}
+
+// GetProfile returns the profile of a user along with their stats. Unless
+// self is set, private details and private scripts are left out.
+func (s *ProfilesService) GetProfile(ctx context.Context, id int, self bool) (*models.Profile, error) {
+	user, err := s.GetUser(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	stats, err := s.partiesRepo.GetUserStats(ctx, id, self)
+	if err != nil {
+		return nil, err
+	}
+
+	profile := &models.Profile{
+		ID:          user.ID,
+		DisplayName: user.DisplayName,
+		AvatarHash:  user.AvatarHash,
+		CreatedAt:   user.CreatedAt,
+		Stats:       *stats,
+	}
+
+	if self {
+		profile.Email = user.Email
+		profile.EmailVerified = user.EmailVerifiedAt != nil
+		profile.MFAEnabled = user.TOTPEnabledAt != nil
+		profile.Language = user.Language
+	}
+
+	return profile, nil
+}
+
+func (s *ProfilesService) UpdateProfile(ctx context.Context, id int, req models.UpdateProfile) (*models.Profile, error) {
+	user, err := s.GetUser(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	columns := []string{"updated_at"}
+	if req.DisplayName != nil {
+		displayName := strings.TrimSpace(*req.DisplayName)
+		if utf8.RuneCountInString(displayName) > maxDisplayNameLength {
+			return nil, ErrDisplayNameTooLong
+		}
+		user.DisplayName = displayName
+		columns = append(columns, "display_name")
+	}
+
+	if req.Language != nil {
+		language := strings.TrimSpace(*req.Language)
+		if len(language) > maxLanguageLength {
+			return nil, ErrInvalidLanguage
+		}
+		user.Language = language
+		columns = append(columns, "language")
+	}
+
+	oldAvatarHash := user.AvatarHash
+	if req.AvatarFile != nil {
+		avatarData, err := io.ReadAll(req.AvatarFile)
+		if err != nil {
+			return nil, err
+		}
+
+		if !strings.HasPrefix(http.DetectContentType(avatarData), "image/") {
+			return nil, ErrAvatarNotImage
+		}
+
+		user.AvatarHash, err = ComputeHashFromReader(bytes.NewReader(avatarData))
+		if err != nil {
+			return nil, err
+		}
+
+		if err := s.imagesStorage.Save(ctx, user.AvatarHash, bytes.NewReader(avatarData)); err != nil {
+			return nil, err
+		}
+		columns = append(columns, "avatar_hash")
+	}
+
+	user.UpdatedAt = time.Now()
+	if err := s.userRepo.UpdateUser(ctx, user, columns...); err != nil {
+		if user.AvatarHash != oldAvatarHash {
+			deleteUnusedFile(ctx, s.filesRepo, s.imagesStorage, user.AvatarHash)
+		}
+		return nil, err
+	}
+
+	// Avatars are stored by their hash, so the old one may still be the
+	// avatar of someone else or an image of a script.
+	if user.AvatarHash != oldAvatarHash {
+		deleteUnusedFile(ctx, s.filesRepo, s.imagesStorage, oldAvatarHash)
+	}
+
+	return s.GetProfile(ctx, id, true)
+}
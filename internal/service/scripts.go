@@ -11,22 +11,39 @@ import (
 
 	"github.com/theWebPartyTime/server/internal/models"
 	"github.com/theWebPartyTime/server/internal/repository"
+	"github.com/theWebPartyTime/server/internal/simulation"
 	"github.com/theWebPartyTime/server/internal/storage"
 
 	"github.com/pelletier/go-toml/v2"
 )
 
+// TestsFailedError rejects a script whose embedded tests did not pass.
+type TestsFailedError struct {
+	Results []simulation.TestResult
+}
+
+func (e *TestsFailedError) Error() string {
+	return "embedded script tests failed"
+}
+
 type ScriptsService struct {
 	scriptsRepo    repository.ScriptsRepository
+	userRepo       repository.UserRepository
 	scriptsStorage storage.FilesStorage
 	imagesStorage  storage.FilesStorage
 }
 
-func NewScriptsService(scriptsRepo repository.ScriptsRepository, scriptsStorage storage.FilesStorage, imagesStorage storage.FilesStorage) *ScriptsService {
-	return &ScriptsService{scriptsRepo: scriptsRepo, scriptsStorage: scriptsStorage, imagesStorage: imagesStorage}
+func NewScriptsService(scriptsRepo repository.ScriptsRepository, userRepo repository.UserRepository, scriptsStorage storage.FilesStorage, imagesStorage storage.FilesStorage) *ScriptsService {
+	return &ScriptsService{scriptsRepo: scriptsRepo, userRepo: userRepo, scriptsStorage: scriptsStorage, imagesStorage: imagesStorage}
 }
 
 func (s *ScriptsService) UploadScript(ctx context.Context, scriptRequest models.CreateScript) error {
+	if scriptRequest.Public {
+		if err := s.checkCanPublish(ctx, scriptRequest.CreatorId); err != nil {
+			return err
+		}
+	}
+
 	scriptData, err := io.ReadAll(scriptRequest.ScriptFile)
 	if err != nil {
 		return err
@@ -37,6 +54,12 @@ func (s *ScriptsService) UploadScript(ctx context.Context, scriptRequest models.
 		return err
 	}
 
+	if scriptRequest.RequireTests {
+		if err := checkTests(ctx, scriptData); err != nil {
+			return err
+		}
+	}
+
 	scriptHash, err := ComputeHashFromReader(bytes.NewReader(scriptData))
 	if err != nil {
 		return err
@@ -83,6 +106,18 @@ func (s *ScriptsService) UploadScript(ctx context.Context, scriptRequest models.
 }
 
 func (s *ScriptsService) UpdateScript(ctx context.Context, oldScriptHash string, oldCoverHash string, scriptRequest models.UpdateScript) error {
+	if scriptRequest.Public {
+		current, err := s.scriptsRepo.GetScriptByHash(ctx, oldScriptHash)
+		if err != nil {
+			return err
+		}
+		if !current.Public {
+			if err := s.checkCanPublish(ctx, current.CreatorId); err != nil {
+				return err
+			}
+		}
+	}
+
 	if scriptRequest.CoverFile != nil {
 		err := s.UpdateCover(ctx, oldScriptHash, scriptRequest.CoverFile)
 		if err != nil {
@@ -102,6 +137,13 @@ func (s *ScriptsService) UpdateScript(ctx context.Context, oldScriptHash string,
 		if err != nil {
 			return err
 		}
+
+		if scriptRequest.RequireTests {
+			if err := checkTests(ctx, scriptData); err != nil {
+				return err
+			}
+		}
+
 		newScriptHash, err = ComputeHashFromReader(bytes.NewReader(scriptData))
 		if err != nil {
 			return err
@@ -155,6 +197,19 @@ func (s *ScriptsService) UpdateScript(ctx context.Context, oldScriptHash string,
 	return nil
 }
 
+// checkCanPublish keeps users who have not verified their email address from
+// listing scripts publicly.
+func (s *ScriptsService) checkCanPublish(ctx context.Context, userId int) error {
+	user, err := s.userRepo.GetUserByID(ctx, userId)
+	if err != nil {
+		return err
+	}
+	if user.EmailVerifiedAt == nil {
+		return ErrEmailNotVerified
+	}
+	return nil
+}
+
 func (s *ScriptsService) DeleteScript(ctx context.Context, scriptHash string) error {
 	script, err := s.scriptsRepo.GetScriptByHash(ctx, scriptHash)
 	if err != nil {
@@ -281,6 +336,34 @@ func (s *ScriptsService) DeleteCover(ctx context.Context, scriptHash string) err
 	return nil
 }
 
+func (s *ScriptsService) SimulateScript(ctx context.Context, scriptHash string, config simulation.Config) (*simulation.Trace, error) {
+	scriptData, err := s.ReadScript(ctx, scriptHash)
+	if err != nil {
+		return nil, err
+	}
+
+	return simulation.Run(ctx, scriptHash, string(scriptData), config)
+}
+
+func (s *ScriptsService) TestScript(ctx context.Context, scriptHash string) (*simulation.TestReport, error) {
+	scriptData, err := s.ReadScript(ctx, scriptHash)
+	if err != nil {
+		return nil, err
+	}
+
+	return simulation.RunTests(ctx, scriptHash, string(scriptData))
+}
+
+func (s *ScriptsService) ReadScript(ctx context.Context, scriptHash string) ([]byte, error) {
+	file, err := s.scriptsStorage.Open(ctx, scriptHash)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return io.ReadAll(file)
+}
+
 func (s *ScriptsService) GetScriptByHash(ctx context.Context, hash string) (*models.Script, error) {
 	return s.scriptsRepo.GetScriptByHash(ctx, hash)
 }
@@ -293,6 +376,19 @@ func ComputeHashFromReader(r io.Reader) (string, error) {
 	return hex.EncodeToString(hasher.Sum(nil)), nil
 }
 
+func checkTests(ctx context.Context, scriptData []byte) error {
+	report, err := simulation.RunTests(ctx, "upload", string(scriptData))
+	if err != nil {
+		return err
+	}
+
+	if !simulation.Passed(report.Results) {
+		return &TestsFailedError{Results: report.Results}
+	}
+
+	return nil
+}
+
 func ValidateToml(data []byte) error {
 	var v map[string]interface{}
 	if err := toml.Unmarshal(data, &v); err != nil {
@@ -0,0 +1,209 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/theWebPartyTime/server/internal/models"
+	"github.com/theWebPartyTime/server/internal/repository"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidRefreshToken = errors.New("invalid refresh token")
+	ErrSessionNotFound     = errors.New("session not found")
+	ErrUserBanned          = errors.New("account is banned")
+)
+
+type SessionsService struct {
+	sessionsRepo repository.SessionsRepository
+	userRepo     repository.UserRepository
+	refreshTTL   time.Duration
+}
+
+func NewSessionsService(sessionsRepo repository.SessionsRepository, userRepo repository.UserRepository, refreshTTL time.Duration) *SessionsService {
+	return &SessionsService{sessionsRepo: sessionsRepo, userRepo: userRepo, refreshTTL: refreshTTL}
+}
+
+// StartSession logs a user in on a new device and returns its first refresh
+// token.
+func (s *SessionsService) StartSession(ctx context.Context, user *models.User, userAgent string, ip string) (*models.Session, string, error) {
+	if user.BannedAt != nil {
+		return nil, "", ErrUserBanned
+	}
+
+	refreshToken, token, err := s.newRefreshToken()
+	if err != nil {
+		return nil, "", err
+	}
+
+	now := time.Now()
+	session := &models.Session{
+		ID:         uuid.NewString(),
+		UserId:     user.ID,
+		UserAgent:  userAgent,
+		IP:         ip,
+		CreatedAt:  now,
+		LastUsedAt: now,
+	}
+
+	if err := s.sessionsRepo.CreateSession(ctx, session, token); err != nil {
+		return nil, "", err
+	}
+
+	return session, refreshToken, nil
+}
+
+// Refresh exchanges a refresh token for the next one of its session. A token
+// can only be exchanged once: presenting it again means it leaked, and the
+// whole session gets revoked.
+func (s *SessionsService) Refresh(ctx context.Context, refreshToken string) (*models.User, *models.Session, string, error) {
+	used, session, err := s.lookup(ctx, refreshToken)
+	if err != nil {
+		return nil, nil, "", err
+	}
+
+	if used.UsedAt != nil {
+		s.revokeReused(ctx, session)
+		return nil, nil, "", ErrInvalidRefreshToken
+	}
+
+	if time.Now().After(used.ExpiresAt) {
+		return nil, nil, "", ErrInvalidRefreshToken
+	}
+
+	user, err := s.userRepo.GetUserByID(ctx, session.UserId)
+	if err != nil {
+		return nil, nil, "", err
+	}
+	if user.BannedAt != nil {
+		return nil, nil, "", ErrUserBanned
+	}
+
+	nextToken, next, err := s.newRefreshToken()
+	if err != nil {
+		return nil, nil, "", err
+	}
+
+	err = s.sessionsRepo.RotateRefreshToken(ctx, used, next)
+	if errors.Is(err, repository.ErrTokenAlreadyUsed) {
+		s.revokeReused(ctx, session)
+		return nil, nil, "", ErrInvalidRefreshToken
+	}
+	if err != nil {
+		return nil, nil, "", err
+	}
+
+	return user, session, nextToken, nil
+}
+
+// Logout revokes the session a refresh token belongs to.
+func (s *SessionsService) Logout(ctx context.Context, refreshToken string) error {
+	_, session, err := s.lookup(ctx, refreshToken)
+	if err != nil {
+		return err
+	}
+
+	return s.sessionsRepo.RevokeSession(ctx, session.ID)
+}
+
+// GetUserSessions lists the active sessions of a user, marking the current
+// one.
+func (s *SessionsService) GetUserSessions(ctx context.Context, userId int, current string) ([]*models.Session, error) {
+	sessions, err := s.sessionsRepo.GetUserSessions(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, session := range sessions {
+		session.Current = session.ID == current
+	}
+
+	return sessions, nil
+}
+
+func (s *SessionsService) RevokeSession(ctx context.Context, userId int, sessionId string) error {
+	session, err := s.sessionsRepo.GetSession(ctx, sessionId)
+	if err != nil || session.UserId != userId || session.RevokedAt != nil {
+		return ErrSessionNotFound
+	}
+
+	return s.sessionsRepo.RevokeSession(ctx, sessionId)
+}
+
+// SessionActive reports whether a session exists and was not revoked.
+func (s *SessionsService) SessionActive(ctx context.Context, id string) (bool, error) {
+	session, err := s.sessionsRepo.GetSession(ctx, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return session.RevokedAt == nil, nil
+}
+
+// RevokeOtherSessions logs a user out everywhere but on the current session.
+func (s *SessionsService) RevokeOtherSessions(ctx context.Context, userId int, current string) error {
+	return s.sessionsRepo.RevokeUserSessions(ctx, userId, current)
+}
+
+func (s *SessionsService) lookup(ctx context.Context, refreshToken string) (*models.RefreshToken, *models.Session, error) {
+	token, err := s.sessionsRepo.GetRefreshToken(ctx, hashToken(refreshToken))
+	if err != nil {
+		return nil, nil, ErrInvalidRefreshToken
+	}
+
+	session, err := s.sessionsRepo.GetSession(ctx, token.SessionId)
+	if err != nil || session.RevokedAt != nil {
+		return nil, nil, ErrInvalidRefreshToken
+	}
+
+	return token, session, nil
+}
+
+func (s *SessionsService) revokeReused(ctx context.Context, session *models.Session) {
+	log.Printf("refresh token reuse detected, revoking session %s of user %d", session.ID, session.UserId)
+
+	if err := s.sessionsRepo.RevokeSession(ctx, session.ID); err != nil {
+		log.Println(err.Error())
+	}
+}
+
+func (s *SessionsService) newRefreshToken() (string, *models.RefreshToken, error) {
+	refreshToken, err := randomToken()
+	if err != nil {
+		return "", nil, err
+	}
+
+	now := time.Now()
+
+	return refreshToken, &models.RefreshToken{
+		TokenHash: hashToken(refreshToken),
+		CreatedAt: now,
+		ExpiresAt: now.Add(s.refreshTTL),
+	}, nil
+}
+
+// randomToken returns an opaque token to hand out; only its hashToken is
+// ever stored.
+func randomToken() (string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(secret), nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,92 @@
+package simulation
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"strings"
+
+	"github.com/theWebPartyTime/server/internal/partyflow"
+)
+
+type Strategy string
+
+const (
+	Random  Strategy = "random"
+	Correct Strategy = "correct"
+	Never   Strategy = "never"
+
+	// Scripted bots send in exactly the answers given to them per query
+	// and are what embedded script tests play with.
+	Scripted Strategy = "scripted"
+)
+
+// IsValid reports whether strategy can be requested without answers.
+func (strategy Strategy) IsValid() bool {
+	return strategy == Random || strategy == Correct || strategy == Never
+}
+
+type Bot struct {
+	ID       string            `json:"id"`
+	Nickname string            `json:"nickname"`
+	Strategy Strategy          `json:"strategy"`
+	Answers  map[string]string `json:"answers,omitempty"`
+}
+
+// answer decides what the bot sends in for a query. Queries checked with
+// "pick" or "vote" have no answer known in advance, so a correct bot guesses
+// among the limits there, and votes for the first candidate in a vote.
+func (bot Bot) answer(partyQuery *partyflow.PartyQuery, rng *rand.Rand) (string, bool) {
+	if bot.Strategy == Never || partyQuery.Input == nil {
+		return "", false
+	}
+
+	if bot.Strategy == Scripted {
+		answer, ok := bot.Answers[partyQuery.Name]
+		return answer, ok
+	}
+
+	queryType, _ := partyQuery.Input["type"].(string)
+
+	if strings.HasPrefix(queryType, "vote ") {
+		candidates, _ := partyQuery.Input["candidates"].(map[string]string)
+		if len(candidates) == 0 {
+			return "", false
+		}
+
+		users := make([]string, 0, len(candidates))
+		for user := range candidates {
+			users = append(users, user)
+		}
+		slices.Sort(users)
+
+		if bot.Strategy == Correct {
+			return users[0], true
+		}
+		return users[rng.IntN(len(users))], true
+	}
+
+	options := []string{}
+	if limits, ok := partyQuery.Input["limits"].([]any); ok {
+		for _, limit := range limits {
+			options = append(options, fmt.Sprint(limit))
+		}
+	}
+
+	correct, literal := partyQuery.Input["correct"].(string)
+	literal = literal && correct != "pick" && correct != "vote"
+
+	if bot.Strategy == Correct && literal {
+		return correct, true
+	}
+
+	if literal {
+		options = append(options, correct)
+	}
+
+	if len(options) == 0 {
+		return fmt.Sprint(rng.IntN(100)), true
+	}
+
+	return options[rng.IntN(len(options))], true
+}
@@ -0,0 +1,88 @@
+package simulation
+
+import (
+	"sync"
+	"time"
+)
+
+type waiter struct {
+	deadline time.Time
+	fire     func(time.Time)
+}
+
+// VirtualClock only moves when the PartyFlow settles: it jumps straight to
+// the earliest pending deadline and drops every other waiter, since those
+// belonged to move conditions that have just lost.
+type VirtualClock struct {
+	mu      sync.Mutex
+	start   time.Time
+	now     time.Time
+	pending []waiter
+	onStall func()
+}
+
+func NewVirtualClock(start time.Time, onStall func()) *VirtualClock {
+	return &VirtualClock{
+		start:   start,
+		now:     start,
+		pending: nil,
+		onStall: onStall,
+	}
+}
+
+func (clock *VirtualClock) Now() time.Time {
+	clock.mu.Lock()
+	defer clock.mu.Unlock()
+	return clock.now
+}
+
+func (clock *VirtualClock) Elapsed() time.Duration {
+	return clock.Now().Sub(clock.start)
+}
+
+func (clock *VirtualClock) After(d time.Duration) <-chan time.Time {
+	channel := make(chan time.Time, 1)
+	clock.add(d, func(now time.Time) { channel <- now })
+	return channel
+}
+
+// Signal is After shaped like a PartyFlow condition channel.
+func (clock *VirtualClock) Signal(d time.Duration) <-chan struct{} {
+	channel := make(chan struct{}, 1)
+	clock.add(d, func(time.Time) { channel <- struct{}{} })
+	return channel
+}
+
+func (clock *VirtualClock) Settle() {
+	clock.mu.Lock()
+
+	if len(clock.pending) == 0 {
+		clock.mu.Unlock()
+		clock.onStall()
+		return
+	}
+
+	earliest := clock.pending[0]
+	for _, waiter := range clock.pending[1:] {
+		if waiter.deadline.Before(earliest.deadline) {
+			earliest = waiter
+		}
+	}
+
+	if earliest.deadline.After(clock.now) {
+		clock.now = earliest.deadline
+	}
+
+	clock.pending = nil
+	now := clock.now
+	clock.mu.Unlock()
+
+	earliest.fire(now)
+}
+
+func (clock *VirtualClock) add(d time.Duration, fire func(time.Time)) {
+	clock.mu.Lock()
+	defer clock.mu.Unlock()
+
+	clock.pending = append(clock.pending, waiter{deadline: clock.now.Add(d), fire: fire})
+}
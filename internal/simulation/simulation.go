@@ -0,0 +1,273 @@
+package simulation
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/theWebPartyTime/server/internal/input"
+	"github.com/theWebPartyTime/server/internal/partyflow"
+	"github.com/theWebPartyTime/server/internal/room"
+)
+
+type Config struct {
+	Bots     []Bot
+	Seed     uint64
+	MaxSteps int
+	Language string
+}
+
+type Payload struct {
+	Audience string         `json:"audience"`
+	Data     map[string]any `json:"data"`
+}
+
+type Step struct {
+	Step      int               `json:"step"`
+	Query     string            `json:"query"`
+	ElapsedMs int64             `json:"elapsed_ms"`
+	Payloads  []Payload         `json:"payloads"`
+	Inputs    map[string]string `json:"inputs"`
+	Winners   []string          `json:"winners"`
+	Round     *partyflow.Round  `json:"round,omitempty"`
+}
+
+type RoundResult struct {
+	Round     *partyflow.Round              `json:"round"`
+	Standings map[string]partyflow.Standing `json:"standings"`
+}
+
+type Trace struct {
+	Bots      []Bot                         `json:"bots"`
+	Steps     []Step                        `json:"steps"`
+	Rounds    []RoundResult                 `json:"rounds"`
+	Standings map[string]partyflow.Standing `json:"standings"`
+	Panic     string                        `json:"panic,omitempty"`
+	Stalled   bool                          `json:"stalled"`
+	Truncated bool                          `json:"truncated"`
+	ElapsedMs int64                         `json:"elapsed_ms"`
+	Log       []string                      `json:"log"`
+}
+
+type simulation struct {
+	ctx       context.Context
+	config    Config
+	partyFlow *partyflow.PartyFlow
+	clock     *VirtualClock
+	rng       *rand.Rand
+	inputs    map[string]room.Input
+	trace     *Trace
+	stopping  atomic.Bool
+}
+
+func DefaultConfig() Config {
+	return Config{
+		Bots:     NewBots(3, Random),
+		Seed:     1,
+		MaxSteps: 500,
+	}
+}
+
+func NewBots(count int, strategy Strategy) []Bot {
+	bots := make([]Bot, count)
+	for i := range bots {
+		bots[i] = Bot{
+			ID:       fmt.Sprintf("bot-%d", i+1),
+			Nickname: fmt.Sprintf("Bot %d", i+1),
+			Strategy: strategy,
+		}
+	}
+
+	return bots
+}
+
+// Run plays a WebPartySpec from start to end with bots instead of players.
+// Nothing waits on wall time: timers are resolved by a virtual clock, and
+// the run is cut short once MaxSteps is exceeded or ctx is done.
+func Run(ctx context.Context, debugName string, webPartySpec string, config Config) (*Trace, error) {
+	var logs bytes.Buffer
+
+	partyFlow, err := partyflow.New().FromString(debugName, webPartySpec, &logs)
+	if err != nil {
+		return nil, err
+	}
+
+	sim := &simulation{
+		ctx:       ctx,
+		config:    config,
+		partyFlow: partyFlow,
+		rng:       rand.New(rand.NewPCG(config.Seed, config.Seed)),
+		inputs:    make(map[string]room.Input),
+		trace: &Trace{
+			Bots:   config.Bots,
+			Steps:  []Step{},
+			Rounds: []RoundResult{},
+		},
+	}
+	sim.clock = NewVirtualClock(time.Unix(0, 0), sim.stalled)
+
+	sim.attach()
+	partyFlow.Start()
+
+	sim.trace.Standings = partyFlow.GetStandings()
+	sim.trace.ElapsedMs = sim.clock.Elapsed().Milliseconds()
+	sim.trace.Log = strings.Split(strings.TrimSpace(logs.String()), "\n")
+
+	return sim.trace, nil
+}
+
+func (sim *simulation) attach() {
+	partyFlow := sim.partyFlow
+
+	partyFlow.SetClock(sim.clock)
+	partyFlow.AddInputChecker("text", sim.textChecker())
+	partyFlow.AddCondition("timer", sim.timer, nil)
+	partyFlow.AddCondition("inputBased", sim.inputBased, nil)
+
+	partyFlow.OnQuery(sim.onQuery)
+
+	partyFlow.OnGetWinners(func(partyQuery *partyflow.PartyQuery) []string {
+		winners := room.Winners(partyFlow, partyQuery, sim.inputs)
+		if step := sim.step(partyQuery.Step); step != nil {
+			step.Winners = winners
+		}
+		return winners
+	})
+
+	partyFlow.OnGetInputs(func(partyQuery *partyflow.PartyQuery) map[string]string {
+		return room.Messages(sim.inputs)
+	})
+
+	partyFlow.OnMove(func() {
+		clear(sim.inputs)
+	})
+
+	partyFlow.OnRoundFinished(func(round *partyflow.Round, standings map[string]partyflow.Standing) {
+		sim.trace.Rounds = append(sim.trace.Rounds, RoundResult{Round: round, Standings: standings})
+	})
+
+	partyFlow.OnPanic(func(r any) {
+		sim.trace.Panic = fmt.Sprint(r)
+	})
+}
+
+func (sim *simulation) onQuery(partyQuery *partyflow.PartyQuery) {
+	if partyQuery.Step > sim.config.MaxSteps || sim.ctx.Err() != nil {
+		sim.trace.Truncated = true
+		sim.stop()
+		return
+	}
+
+	step := Step{
+		Step:      partyQuery.Step,
+		Query:     partyQuery.Name,
+		ElapsedMs: sim.clock.Elapsed().Milliseconds(),
+		Payloads:  []Payload{},
+		Inputs:    make(map[string]string),
+		Winners:   []string{},
+		Round:     partyQuery.Round,
+	}
+
+	context := partyflow.TemplateContext{
+		Step:      partyQuery.Step,
+		Players:   len(sim.config.Bots),
+		Nicknames: make(map[string]string),
+	}
+	for _, bot := range sim.config.Bots {
+		context.Nicknames[bot.ID] = bot.Nickname
+	}
+
+	localized := *partyQuery
+	localized.Input = sim.partyFlow.Prepare(partyQuery.Input, sim.config.Language, context)
+	localized.Layout = sim.partyFlow.Prepare(partyQuery.SpectatorLayout(), sim.config.Language, context)
+
+	if localized.Input != nil {
+		step.Payloads = append(step.Payloads, Payload{Audience: "players", Data: localized.PlayerInput()})
+	}
+
+	if localized.Layout != nil {
+		step.Payloads = append(step.Payloads, Payload{Audience: "spectators", Data: localized.Layout})
+	}
+
+	for _, bot := range sim.config.Bots {
+		message, answered := bot.answer(&localized, sim.rng)
+		if !answered {
+			continue
+		}
+
+		sim.inputs[bot.ID] = room.Input{
+			Type: "input",
+			Content: map[string]any{
+				"step":    float64(partyQuery.Step),
+				"type":    partyQuery.Input["type"],
+				"message": message,
+			},
+		}
+		step.Inputs[bot.ID] = message
+	}
+
+	sim.trace.Steps = append(sim.trace.Steps, step)
+}
+
+func (sim *simulation) step(number int) *Step {
+	if number < 1 || number > len(sim.trace.Steps) {
+		return nil
+	}
+
+	return &sim.trace.Steps[number-1]
+}
+
+func (sim *simulation) timer(data any, args map[string]any) <-chan struct{} {
+	if sim.stopping.Load() {
+		return make(chan struct{})
+	}
+
+	return sim.clock.Signal(time.Duration(data.(int64)) * time.Second)
+}
+
+// inputBased fires right away when every bot has answered the current step,
+// and never otherwise: bots answer as soon as a query is emitted.
+func (sim *simulation) inputBased(data any, args map[string]any) <-chan struct{} {
+	if sim.stopping.Load() || len(sim.config.Bots) == 0 {
+		return make(chan struct{})
+	}
+
+	answered := 0
+	for _, input := range sim.inputs {
+		if input.Content["step"].(float64) == float64(sim.partyFlow.GetStep()) {
+			answered += 1
+		}
+	}
+
+	if answered != len(sim.config.Bots) {
+		return make(chan struct{})
+	}
+
+	return sim.clock.Signal(0)
+}
+
+func (sim *simulation) textChecker() input.Checker {
+	checker := input.GetTextChecker()
+	checker.Pick = func(limits []any) any {
+		return limits[sim.rng.IntN(len(limits))]
+	}
+
+	return checker
+}
+
+func (sim *simulation) stalled() {
+	if !sim.stopping.Load() {
+		sim.trace.Stalled = true
+	}
+
+	sim.stop()
+}
+
+func (sim *simulation) stop() {
+	sim.stopping.Store(true)
+	sim.partyFlow.Stop()
+}
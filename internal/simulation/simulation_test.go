@@ -0,0 +1,207 @@
+package simulation
+
+import (
+	"context"
+	"fmt"
+	"testing"
+)
+
+const quiz = `
+start = "intro"
+
+[intro]
+    [intro.layout]
+    type = "basic"
+    title = "Угадай число"
+
+        [intro.to.guess1]
+        timer = 3
+
+[guess1]
+    [guess1.layout]
+    type = "basic"
+    title = "Угадайте число от 1 до 5"
+
+    [guess1.input]
+    type = "text"
+    limits = ["1", "2", "3", "4", "5"]
+    correct = "3"
+
+        [guess1.to.end]
+        inputBased = true
+        timer = 30
+
+    [guess1.overviewer]
+    type = "winner"
+    timer = 5
+`
+
+func TestSimulationCorrectBots(t *testing.T) {
+	config := DefaultConfig()
+	config.Bots = NewBots(2, Correct)
+
+	trace, err := Run(context.Background(), "quiz", quiz, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if trace.Panic != "" || trace.Stalled || trace.Truncated {
+		t.Fatalf("simulation did not finish cleanly: %+v", trace)
+	}
+
+	visited := []string{}
+	for _, step := range trace.Steps {
+		visited = append(visited, step.Query)
+	}
+
+	if len(visited) != 3 || visited[0] != "intro" || visited[1] != "guess1" {
+		t.Fatalf("unexpected path %v", visited)
+	}
+
+	if len(trace.Steps[1].Winners) != 2 {
+		t.Fatalf("both bots should have won guess1, got %v", trace.Steps[1].Winners)
+	}
+
+	if trace.Standings["bot-1"].WinCount != 1 {
+		t.Fatalf("unexpected standings %v", trace.Standings)
+	}
+
+	// initial wait + intro timer + overviewer timer, and 200ms of judging
+	// after both intro and guess1
+	if trace.ElapsedMs != 9400 {
+		t.Fatalf("unexpected virtual duration %dms", trace.ElapsedMs)
+	}
+}
+
+func TestSimulationNeverBots(t *testing.T) {
+	config := DefaultConfig()
+	config.Bots = NewBots(2, Never)
+
+	trace, err := Run(context.Background(), "quiz", quiz, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(trace.Standings) != 0 {
+		t.Fatalf("nobody should have won, got %v", trace.Standings)
+	}
+
+	if trace.Steps[2].ElapsedMs-trace.Steps[1].ElapsedMs != 30200 {
+		t.Fatalf("guess1 should have waited for its timer")
+	}
+}
+
+const quizTests = quiz + `
+[[tests]]
+name = "only alice guesses"
+path = ["intro", "guess1", "guess1 (overviewer)"]
+
+    [tests.inputs]
+    guess1 = { alice = "3", bob = "4" }
+
+    [tests.winners]
+    guess1 = ["alice"]
+
+[[tests]]
+name = "wrong expectations"
+path = ["intro", "guess1"]
+
+    [tests.inputs]
+    guess1 = { alice = "1" }
+
+    [tests.standings]
+    alice = 1
+`
+
+func TestEmbeddedTests(t *testing.T) {
+	report, err := RunTests(context.Background(), "quiz", quizTests)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	results := report.Results
+	if len(results) != 2 {
+		t.Fatalf("expected 2 results, got %d", len(results))
+	}
+
+	if !results[0].Passed {
+		t.Fatalf("<%s> should pass: %v", results[0].Name, results[0].Diffs)
+	}
+
+	if results[1].Passed || len(results[1].Diffs) != 2 {
+		t.Fatalf("<%s> should fail on path and standings: %v", results[1].Name, results[1].Diffs)
+	}
+}
+
+const rounds = `
+start = "q1"
+
+[[rounds]]
+name = "Warm-up"
+queries = ["q1"]
+
+[[rounds]]
+name = "Final"
+queries = ["q2"]
+summary = { type = "final", timer = 10 }
+
+[q1]
+    [q1.layout]
+    type = "basic"
+    title = "Round {{round.index}} of {{round.total}}"
+
+    [q1.input]
+    type = "text"
+    correct = "a"
+
+        [q1.to.q2]
+        inputBased = true
+
+[q2]
+    [q2.layout]
+    type = "basic"
+    title = "{{round.name}}"
+
+    [q2.input]
+    type = "text"
+    correct = "b"
+
+        [q2.to.end]
+        inputBased = true
+`
+
+func TestSimulationRounds(t *testing.T) {
+	config := DefaultConfig()
+	config.Bots = NewBots(1, Correct)
+
+	trace, err := Run(context.Background(), "rounds", rounds, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := []string{}
+	for _, step := range trace.Steps {
+		path = append(path, step.Query)
+	}
+
+	expected := "[q1 Warm-up (summary) q2 Final (summary)]"
+	if fmt.Sprint(path) != expected {
+		t.Fatalf("expected path %s, got %v", expected, path)
+	}
+
+	if title := trace.Steps[0].Payloads[1].Data["title"]; title != "Round 1 of 2" {
+		t.Fatalf("unexpected q1 title %v", title)
+	}
+
+	if title := trace.Steps[2].Payloads[1].Data["title"]; title != "Final" {
+		t.Fatalf("unexpected q2 title %v", title)
+	}
+
+	if len(trace.Rounds) != 2 || trace.Rounds[1].Standings["bot-1"].WinCount != 1 {
+		t.Fatalf("round standings should be kept per round: %+v", trace.Rounds)
+	}
+
+	if trace.Standings["bot-1"].WinCount != 2 {
+		t.Fatalf("unexpected standings %v", trace.Standings)
+	}
+}
@@ -0,0 +1,151 @@
+package simulation
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"slices"
+
+	"github.com/theWebPartyTime/server/internal/partyflow"
+)
+
+type TestResult struct {
+	Name   string   `json:"name"`
+	Passed bool     `json:"passed"`
+	Diffs  []string `json:"diffs"`
+}
+
+type TestReport struct {
+	Results             []TestResult `json:"tests"`
+	MissingTranslations []string     `json:"missing_translations"`
+}
+
+// RunTests plays every [[tests]] block of a WebPartySpec with scripted bots
+// and compares the outcome with what the test expects.
+func RunTests(ctx context.Context, debugName string, webPartySpec string) (*TestReport, error) {
+	partyFlow, err := partyflow.New().FromString(debugName, webPartySpec, io.Discard)
+	if err != nil {
+		return nil, err
+	}
+
+	results := []TestResult{}
+
+	for _, test := range partyFlow.GetTests() {
+		trace, err := Run(ctx, debugName, webPartySpec, testConfig(test))
+		if err != nil {
+			return nil, err
+		}
+
+		diffs := compare(test, trace)
+		results = append(results, TestResult{
+			Name:   test.Name,
+			Passed: len(diffs) == 0,
+			Diffs:  diffs,
+		})
+	}
+
+	missingTranslations := partyFlow.GetMissingTranslations()
+	if missingTranslations == nil {
+		missingTranslations = []string{}
+	}
+
+	return &TestReport{Results: results, MissingTranslations: missingTranslations}, nil
+}
+
+func Passed(results []TestResult) bool {
+	for _, result := range results {
+		if !result.Passed {
+			return false
+		}
+	}
+
+	return true
+}
+
+func testConfig(test partyflow.TestCase) Config {
+	config := DefaultConfig()
+	config.Seed = test.Seed
+	config.Language = test.Language
+	config.Bots = []Bot{}
+
+	for _, player := range test.Players {
+		answers := make(map[string]string)
+		for queryName, inputs := range test.Inputs {
+			if answer, ok := inputs[player]; ok {
+				answers[queryName] = answer
+			}
+		}
+
+		config.Bots = append(config.Bots, Bot{
+			ID:       player,
+			Nickname: player,
+			Strategy: Scripted,
+			Answers:  answers,
+		})
+	}
+
+	return config
+}
+
+func compare(test partyflow.TestCase, trace *Trace) []string {
+	diffs := []string{}
+
+	if trace.Panic != "" {
+		diffs = append(diffs, fmt.Sprintf("panicked: %s", trace.Panic))
+	}
+
+	if trace.Stalled {
+		diffs = append(diffs, fmt.Sprintf("stalled after step %d: no move condition can be met", len(trace.Steps)))
+	}
+
+	if trace.Truncated {
+		diffs = append(diffs, fmt.Sprintf("did not finish within %d steps", len(trace.Steps)))
+	}
+
+	path := []string{}
+	lastVisit := make(map[string]Step)
+	for _, step := range trace.Steps {
+		path = append(path, step.Query)
+		lastVisit[step.Query] = step
+	}
+
+	if test.Path != nil && !slices.Equal(test.Path, path) {
+		diffs = append(diffs, fmt.Sprintf("path: expected %v, got %v", test.Path, path))
+	}
+
+	queryNames := make([]string, 0, len(test.Winners))
+	for queryName := range test.Winners {
+		queryNames = append(queryNames, queryName)
+	}
+	slices.Sort(queryNames)
+
+	for _, queryName := range queryNames {
+		expected := slices.Sorted(slices.Values(test.Winners[queryName]))
+
+		step, visited := lastVisit[queryName]
+		if !visited {
+			diffs = append(diffs, fmt.Sprintf("winners of <%s>: expected %v, but it was never reached", queryName, expected))
+			continue
+		}
+
+		got := slices.Sorted(slices.Values(step.Winners))
+		if !slices.Equal(expected, got) {
+			diffs = append(diffs, fmt.Sprintf("winners of <%s>: expected %v, got %v", queryName, expected, got))
+		}
+	}
+
+	players := make([]string, 0, len(test.Standings))
+	for player := range test.Standings {
+		players = append(players, player)
+	}
+	slices.Sort(players)
+
+	for _, player := range players {
+		got := trace.Standings[player].WinCount
+		if got != test.Standings[player] {
+			diffs = append(diffs, fmt.Sprintf("standings of <%s>: expected %d wins, got %d", player, test.Standings[player], got))
+		}
+	}
+
+	return diffs
+}
@@ -52,7 +52,7 @@ func (s *LocalFilesStorage) Save(ctx context.Context, hash string, r io.Reader)
 		}
 		return err
 	}
-	log.Print("file %s created", finalPath)
+	log.Printf("file %s created", finalPath)
 
 	return nil
 }
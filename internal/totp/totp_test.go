@@ -0,0 +1,43 @@
+package totp
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCode(t *testing.T) {
+	// Test vectors of RFC 6238, truncated to 6 digits.
+	key := []byte("12345678901234567890")
+	for seconds, want := range map[int64]string{
+		59:          "287082",
+		1111111109:  "081804",
+		1111111111:  "050471",
+		1234567890:  "005924",
+		2000000000:  "279037",
+		20000000000: "353130",
+	} {
+		if code := Code(key, seconds/period); code != want {
+			t.Errorf("code at %d = %s, want %s", seconds, code, want)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	secret := encoding.EncodeToString([]byte("12345678901234567890"))
+	now := time.Unix(1111111109, 0)
+
+	step, ok := Validate(secret, "081804", now)
+	if !ok || step != 1111111109/period {
+		t.Fatalf("Validate = %d, %v", step, ok)
+	}
+
+	if _, ok := Validate(secret, "081804", now.Add(30*time.Second)); !ok {
+		t.Error("code of the previous step was rejected")
+	}
+	if _, ok := Validate(secret, "081804", now.Add(90*time.Second)); ok {
+		t.Error("code of an old step was accepted")
+	}
+	if _, ok := Validate(secret, "000000", now); ok {
+		t.Error("wrong code was accepted")
+	}
+}
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS party_participants;
+DROP TABLE IF EXISTS parties;
@@ -0,0 +1,34 @@
+CREATE TABLE "parties" (
+  "id" BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+  "room_code" varchar NOT NULL,
+  "scenario_id" bigint,
+  "script_hash" varchar NOT NULL,
+  "host_id" bigint,
+  "status" party_status NOT NULL DEFAULT 'active',
+  "started_at" timestamp NOT NULL DEFAULT (now()),
+  "ended_at" timestamp
+);
+
+CREATE TABLE "party_participants" (
+  "id" BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+  "party_id" bigint NOT NULL,
+  "user_id" bigint,
+  "session_id" varchar NOT NULL,
+  "nickname" varchar NOT NULL,
+  "wins" integer NOT NULL DEFAULT 0,
+  "rank" integer NOT NULL DEFAULT 0
+);
+
+ALTER TABLE "parties" ADD FOREIGN KEY ("scenario_id") REFERENCES "scenarios" ("id") ON DELETE SET NULL;
+
+ALTER TABLE "parties" ADD FOREIGN KEY ("host_id") REFERENCES "users" ("id");
+
+ALTER TABLE "party_participants" ADD FOREIGN KEY ("party_id") REFERENCES "parties" ("id") ON DELETE CASCADE;
+
+ALTER TABLE "party_participants" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id");
+
+CREATE INDEX ON "parties" ("host_id");
+
+CREATE INDEX ON "party_participants" ("party_id");
+
+CREATE INDEX ON "party_participants" ("user_id");
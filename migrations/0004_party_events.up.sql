@@ -0,0 +1,14 @@
+CREATE TABLE "party_events" (
+  "id" BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+  "room_code" varchar NOT NULL,
+  "party_id" bigint,
+  "seq" integer NOT NULL,
+  "type" varchar NOT NULL,
+  "session_id" varchar NOT NULL DEFAULT '',
+  "data" jsonb NOT NULL DEFAULT '{}',
+  "occurred_at" timestamp NOT NULL
+);
+
+ALTER TABLE "party_events" ADD FOREIGN KEY ("party_id") REFERENCES "parties" ("id") ON DELETE CASCADE;
+
+CREATE INDEX ON "party_events" ("party_id", "seq");
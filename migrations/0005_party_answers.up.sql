@@ -0,0 +1,20 @@
+CREATE TABLE "party_answers" (
+  "id" BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+  "party_id" bigint NOT NULL,
+  "session_id" varchar NOT NULL,
+  "user_id" bigint,
+  "nickname" varchar NOT NULL,
+  "step" integer NOT NULL,
+  "query" varchar NOT NULL,
+  "answer" varchar NOT NULL DEFAULT '',
+  "answered" boolean NOT NULL DEFAULT false,
+  "correct" boolean NOT NULL DEFAULT false,
+  "points" integer NOT NULL DEFAULT 0,
+  "response_ms" bigint
+);
+
+ALTER TABLE "party_answers" ADD FOREIGN KEY ("party_id") REFERENCES "parties" ("id") ON DELETE CASCADE;
+
+ALTER TABLE "party_answers" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id");
+
+CREATE INDEX ON "party_answers" ("party_id", "step");
@@ -0,0 +1,5 @@
+ALTER TABLE "users" DROP COLUMN IF EXISTS "display_name";
+
+ALTER TABLE "users" DROP COLUMN IF EXISTS "avatar_hash";
+
+ALTER TABLE "users" DROP COLUMN IF EXISTS "language";
@@ -0,0 +1,5 @@
+ALTER TABLE "users" ADD COLUMN "display_name" varchar NOT NULL DEFAULT '';
+
+ALTER TABLE "users" ADD COLUMN "avatar_hash" varchar NOT NULL DEFAULT '';
+
+ALTER TABLE "users" ADD COLUMN "language" varchar NOT NULL DEFAULT '';
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS leaderboard_exclusions;
+DROP TABLE IF EXISTS leaderboard_entries;
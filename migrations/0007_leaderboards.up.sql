@@ -0,0 +1,28 @@
+CREATE TABLE "leaderboard_entries" (
+  "id" BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+  "scenario_id" bigint NOT NULL,
+  "script_hash" varchar NOT NULL,
+  "party_id" bigint NOT NULL,
+  "user_id" bigint NOT NULL,
+  "score" integer NOT NULL,
+  "achieved_at" timestamp NOT NULL
+);
+
+CREATE TABLE "leaderboard_exclusions" (
+  "scenario_id" bigint NOT NULL,
+  "user_id" bigint NOT NULL,
+  "excluded_at" timestamp NOT NULL DEFAULT (now()),
+  PRIMARY KEY ("scenario_id", "user_id")
+);
+
+ALTER TABLE "leaderboard_entries" ADD FOREIGN KEY ("scenario_id") REFERENCES "scenarios" ("id") ON DELETE CASCADE;
+
+ALTER TABLE "leaderboard_entries" ADD FOREIGN KEY ("party_id") REFERENCES "parties" ("id") ON DELETE CASCADE;
+
+ALTER TABLE "leaderboard_entries" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id");
+
+ALTER TABLE "leaderboard_exclusions" ADD FOREIGN KEY ("scenario_id") REFERENCES "scenarios" ("id") ON DELETE CASCADE;
+
+ALTER TABLE "leaderboard_exclusions" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id");
+
+CREATE INDEX ON "leaderboard_entries" ("scenario_id", "achieved_at");
@@ -0,0 +1,11 @@
+CREATE TABLE "user_achievements" (
+  "user_id" bigint NOT NULL,
+  "achievement" varchar NOT NULL,
+  "party_id" bigint,
+  "earned_at" timestamp NOT NULL DEFAULT (now()),
+  PRIMARY KEY ("user_id", "achievement")
+);
+
+ALTER TABLE "user_achievements" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id");
+
+ALTER TABLE "user_achievements" ADD FOREIGN KEY ("party_id") REFERENCES "parties" ("id") ON DELETE SET NULL;
@@ -0,0 +1,24 @@
+CREATE TABLE "sessions" (
+  "id" varchar PRIMARY KEY,
+  "user_id" bigint NOT NULL,
+  "user_agent" varchar NOT NULL DEFAULT '',
+  "ip" varchar NOT NULL DEFAULT '',
+  "created_at" timestamp NOT NULL DEFAULT (now()),
+  "last_used_at" timestamp NOT NULL DEFAULT (now()),
+  "revoked_at" timestamp
+);
+
+CREATE TABLE "refresh_tokens" (
+  "id" BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+  "session_id" varchar NOT NULL,
+  "token_hash" varchar UNIQUE NOT NULL,
+  "created_at" timestamp NOT NULL DEFAULT (now()),
+  "expires_at" timestamp NOT NULL,
+  "used_at" timestamp
+);
+
+ALTER TABLE "sessions" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE;
+
+ALTER TABLE "refresh_tokens" ADD FOREIGN KEY ("session_id") REFERENCES "sessions" ("id") ON DELETE CASCADE;
+
+CREATE INDEX ON "sessions" ("user_id");
@@ -0,0 +1,22 @@
+ALTER TABLE "users" ADD COLUMN "email_verified_at" timestamp;
+
+UPDATE "users" SET "email_verified_at" = COALESCE("created_at", now());
+
+CREATE TYPE "account_token_purpose" AS ENUM (
+  'verify_email',
+  'reset_password'
+);
+
+CREATE TABLE "account_tokens" (
+  "id" BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+  "user_id" bigint NOT NULL,
+  "purpose" account_token_purpose NOT NULL,
+  "token_hash" varchar UNIQUE NOT NULL,
+  "created_at" timestamp NOT NULL DEFAULT (now()),
+  "expires_at" timestamp NOT NULL,
+  "used_at" timestamp
+);
+
+ALTER TABLE "account_tokens" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE;
+
+CREATE INDEX ON "account_tokens" ("user_id", "purpose");
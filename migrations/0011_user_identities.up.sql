@@ -0,0 +1,14 @@
+CREATE TABLE "user_identities" (
+  "id" BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+  "user_id" bigint NOT NULL,
+  "provider" varchar NOT NULL,
+  "subject" varchar NOT NULL,
+  "email" varchar NOT NULL DEFAULT '',
+  "created_at" timestamp NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "user_identities" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE;
+
+CREATE UNIQUE INDEX ON "user_identities" ("provider", "subject");
+
+CREATE INDEX ON "user_identities" ("user_id");
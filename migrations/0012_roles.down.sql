@@ -0,0 +1,7 @@
+ALTER TABLE "users" DROP COLUMN IF EXISTS "ban_reason";
+
+ALTER TABLE "users" DROP COLUMN IF EXISTS "banned_at";
+
+ALTER TABLE "users" DROP COLUMN IF EXISTS "role";
+
+DROP TYPE IF EXISTS user_role;
@@ -0,0 +1,11 @@
+CREATE TYPE "user_role" AS ENUM (
+  'user',
+  'moderator',
+  'admin'
+);
+
+ALTER TABLE "users" ADD COLUMN "role" user_role NOT NULL DEFAULT 'user';
+
+ALTER TABLE "users" ADD COLUMN "banned_at" timestamp;
+
+ALTER TABLE "users" ADD COLUMN "ban_reason" varchar NOT NULL DEFAULT '';
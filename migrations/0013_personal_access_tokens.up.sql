@@ -0,0 +1,16 @@
+CREATE TABLE "personal_access_tokens" (
+  "id" BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+  "user_id" bigint NOT NULL,
+  "name" varchar NOT NULL,
+  "prefix" varchar NOT NULL,
+  "token_hash" varchar UNIQUE NOT NULL,
+  "scopes" jsonb NOT NULL DEFAULT '[]',
+  "created_at" timestamp NOT NULL DEFAULT (now()),
+  "expires_at" timestamp,
+  "last_used_at" timestamp,
+  "revoked_at" timestamp
+);
+
+ALTER TABLE "personal_access_tokens" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE;
+
+CREATE INDEX ON "personal_access_tokens" ("user_id");
@@ -0,0 +1,16 @@
+ALTER TABLE "users" ADD COLUMN "totp_secret" varchar NOT NULL DEFAULT '';
+
+ALTER TABLE "users" ADD COLUMN "totp_enabled_at" timestamp;
+
+ALTER TABLE "users" ADD COLUMN "totp_last_step" bigint NOT NULL DEFAULT 0;
+
+CREATE TABLE "recovery_codes" (
+  "id" BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+  "user_id" bigint NOT NULL,
+  "code_hash" varchar NOT NULL,
+  "used_at" timestamp
+);
+
+ALTER TABLE "recovery_codes" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE;
+
+CREATE INDEX ON "recovery_codes" ("user_id");
@@ -0,0 +1,5 @@
+ALTER TABLE "party_answers" DROP COLUMN IF EXISTS "guest_id";
+
+ALTER TABLE "party_participants" DROP COLUMN IF EXISTS "guest_id";
+
+DROP TABLE IF EXISTS guests;
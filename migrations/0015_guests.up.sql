@@ -0,0 +1,20 @@
+CREATE TABLE "guests" (
+  "id" varchar PRIMARY KEY,
+  "created_at" timestamp NOT NULL DEFAULT (now()),
+  "merged_into" bigint,
+  "merged_at" timestamp
+);
+
+ALTER TABLE "guests" ADD FOREIGN KEY ("merged_into") REFERENCES "users" ("id") ON DELETE SET NULL;
+
+ALTER TABLE "party_participants" ADD COLUMN "guest_id" varchar;
+
+ALTER TABLE "party_answers" ADD COLUMN "guest_id" varchar;
+
+ALTER TABLE "party_participants" ADD FOREIGN KEY ("guest_id") REFERENCES "guests" ("id") ON DELETE SET NULL;
+
+ALTER TABLE "party_answers" ADD FOREIGN KEY ("guest_id") REFERENCES "guests" ("id") ON DELETE SET NULL;
+
+CREATE INDEX ON "party_participants" ("guest_id");
+
+CREATE INDEX ON "party_answers" ("guest_id");
@@ -0,0 +1,43 @@
+ALTER TABLE "user_achievements" DROP CONSTRAINT "user_achievements_user_id_fkey";
+
+ALTER TABLE "user_achievements" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id");
+
+ALTER TABLE "leaderboard_exclusions" DROP CONSTRAINT "leaderboard_exclusions_user_id_fkey";
+
+ALTER TABLE "leaderboard_exclusions" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id");
+
+ALTER TABLE "leaderboard_entries" DROP CONSTRAINT "leaderboard_entries_user_id_fkey";
+
+ALTER TABLE "leaderboard_entries" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id");
+
+ALTER TABLE "party_answers" DROP CONSTRAINT "party_answers_user_id_fkey";
+
+ALTER TABLE "party_answers" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id");
+
+ALTER TABLE "party_participants" DROP CONSTRAINT "party_participants_user_id_fkey";
+
+ALTER TABLE "party_participants" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id");
+
+ALTER TABLE "parties" DROP CONSTRAINT "parties_host_id_fkey";
+
+ALTER TABLE "parties" ADD FOREIGN KEY ("host_id") REFERENCES "users" ("id");
+
+ALTER TABLE "assets" DROP CONSTRAINT "assets_scenario_id_fkey";
+
+ALTER TABLE "assets" ADD FOREIGN KEY ("scenario_id") REFERENCES "scenarios" ("id");
+
+ALTER TABLE "assets" DROP CONSTRAINT "assets_uploaded_by_fkey";
+
+ALTER TABLE "assets" ADD FOREIGN KEY ("uploaded_by") REFERENCES "users" ("id");
+
+UPDATE "assets" SET "uploaded_by" = "scenarios"."creator_id"
+FROM "scenarios"
+WHERE "assets"."scenario_id" = "scenarios"."id" AND "assets"."uploaded_by" IS NULL;
+
+DELETE FROM "assets" WHERE "uploaded_by" IS NULL;
+
+ALTER TABLE "assets" ALTER COLUMN "uploaded_by" SET NOT NULL;
+
+ALTER TABLE "scenarios" DROP CONSTRAINT "scenarios_creator_id_fkey";
+
+ALTER TABLE "scenarios" ADD FOREIGN KEY ("creator_id") REFERENCES "users" ("id");
@@ -0,0 +1,37 @@
+ALTER TABLE "scenarios" DROP CONSTRAINT "scenarios_creator_id_fkey";
+
+ALTER TABLE "scenarios" ADD FOREIGN KEY ("creator_id") REFERENCES "users" ("id") ON DELETE RESTRICT;
+
+ALTER TABLE "assets" ALTER COLUMN "uploaded_by" DROP NOT NULL;
+
+ALTER TABLE "assets" DROP CONSTRAINT "assets_uploaded_by_fkey";
+
+ALTER TABLE "assets" ADD FOREIGN KEY ("uploaded_by") REFERENCES "users" ("id") ON DELETE SET NULL;
+
+ALTER TABLE "assets" DROP CONSTRAINT "assets_scenario_id_fkey";
+
+ALTER TABLE "assets" ADD FOREIGN KEY ("scenario_id") REFERENCES "scenarios" ("id") ON DELETE CASCADE;
+
+ALTER TABLE "parties" DROP CONSTRAINT "parties_host_id_fkey";
+
+ALTER TABLE "parties" ADD FOREIGN KEY ("host_id") REFERENCES "users" ("id") ON DELETE SET NULL;
+
+ALTER TABLE "party_participants" DROP CONSTRAINT "party_participants_user_id_fkey";
+
+ALTER TABLE "party_participants" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE SET NULL;
+
+ALTER TABLE "party_answers" DROP CONSTRAINT "party_answers_user_id_fkey";
+
+ALTER TABLE "party_answers" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE SET NULL;
+
+ALTER TABLE "leaderboard_entries" DROP CONSTRAINT "leaderboard_entries_user_id_fkey";
+
+ALTER TABLE "leaderboard_entries" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE;
+
+ALTER TABLE "leaderboard_exclusions" DROP CONSTRAINT "leaderboard_exclusions_user_id_fkey";
+
+ALTER TABLE "leaderboard_exclusions" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE;
+
+ALTER TABLE "user_achievements" DROP CONSTRAINT "user_achievements_user_id_fkey";
+
+ALTER TABLE "user_achievements" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE;